@@ -0,0 +1,318 @@
+package evaluation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	OpIs                    = "IS"
+	OpIsNot                 = "IS_NOT"
+	OpContains              = "CONTAINS"
+	OpDoesNotContain        = "DOES_NOT_CONTAIN"
+	OpLess                  = "LESS"
+	OpLessOrEqual           = "LESS_OR_EQUAL"
+	OpGreater               = "GREATER"
+	OpGreaterOrEqual        = "GREATER_OR_EQUAL"
+	OpVersionLess           = "VERSION_LESS"
+	OpVersionLessOrEqual    = "VERSION_LESS_OR_EQUAL"
+	OpVersionGreater        = "VERSION_GREATER"
+	OpVersionGreaterOrEqual = "VERSION_GREATER_OR_EQUAL"
+	OpSetIs                 = "SET_IS"
+	OpSetIsNot              = "SET_IS_NOT"
+	OpSetContains           = "SET_CONTAINS"
+	OpSetDoesNotContain     = "SET_DOES_NOT_CONTAIN"
+	OpSetContainsAny        = "SET_CONTAINS_ANY"
+	OpSetDoesNotContainAny  = "SET_DOES_NOT_CONTAIN_ANY"
+	OpRegexMatch            = "REGEX_MATCH"
+	OpRegexDoesNotMatch     = "REGEX_DOES_NOT_MATCH"
+)
+
+// noneValue in a condition's values matches users without the property.
+const noneValue = "(none)"
+
+func normalizeOp(op string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(op), " ", "_"))
+}
+
+func matchConditions(conditions []*Condition, t *target) bool {
+	for _, c := range conditions {
+		if c != nil && !matchCondition(c, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchCondition(c *Condition, t *target) bool {
+	op := normalizeOp(c.Op)
+	value := t.value(c.Prop)
+	if isNone(value) {
+		return matchNone(op, c.Values)
+	}
+	switch op {
+	case OpSetIs, OpSetIsNot, OpSetContains, OpSetDoesNotContain, OpSetContainsAny, OpSetDoesNotContainAny:
+		return matchSet(op, stringValues(value), c.Values)
+	}
+	// Scalar operators applied to a list property match on any element.
+	matched := false
+	for _, v := range stringValues(value) {
+		if matchString(op, v, c) {
+			matched = true
+			break
+		}
+	}
+	if negated(op) {
+		return !matched
+	}
+	return matched
+}
+
+func negated(op string) bool {
+	switch op {
+	case OpIsNot, OpDoesNotContain, OpRegexDoesNotMatch:
+		return true
+	}
+	return false
+}
+
+func matchNone(op string, values []string) bool {
+	hasNone := contains(values, noneValue)
+	switch op {
+	case OpIs:
+		return hasNone
+	case OpIsNot:
+		return !hasNone
+	case OpDoesNotContain, OpSetIsNot, OpSetDoesNotContain, OpSetDoesNotContainAny, OpRegexDoesNotMatch:
+		return true
+	}
+	return false
+}
+
+// matchString evaluates the positive form of op for a single value; negated
+// operators are resolved by the caller.
+func matchString(op, value string, c *Condition) bool {
+	values := c.Values
+	switch op {
+	case OpIs, OpIsNot:
+		return contains(values, value)
+	case OpContains, OpDoesNotContain:
+		lower := strings.ToLower(value)
+		for _, v := range values {
+			if strings.Contains(lower, strings.ToLower(v)) {
+				return true
+			}
+		}
+		return false
+	case OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual:
+		for _, v := range values {
+			if compareResult(op, compareValues(value, v)) {
+				return true
+			}
+		}
+		return false
+	case OpVersionLess, OpVersionLessOrEqual, OpVersionGreater, OpVersionGreaterOrEqual:
+		for _, v := range values {
+			cmp, ok := compareVersions(value, v)
+			if ok && compareResult(op, cmp) {
+				return true
+			}
+		}
+		return false
+	case OpRegexMatch, OpRegexDoesNotMatch:
+		for i := range values {
+			re := c.regexp(i)
+			if re != nil && re.MatchString(value) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// regexp returns the compiled i-th value, compiling it now if Prepare has
+// not run. It returns nil for an invalid pattern.
+func (c *Condition) regexp(i int) *regexp.Regexp {
+	if c.regexps != nil {
+		return c.regexps[i]
+	}
+	re, err := regexp.Compile(c.Values[i])
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+func matchSet(op string, value, values []string) bool {
+	switch op {
+	case OpSetIs:
+		return sameSet(value, values)
+	case OpSetIsNot:
+		return !sameSet(value, values)
+	case OpSetContains:
+		return containsAll(value, values)
+	case OpSetDoesNotContain:
+		return !containsAll(value, values)
+	case OpSetContainsAny:
+		return containsAny(value, values)
+	case OpSetDoesNotContainAny:
+		return !containsAny(value, values)
+	}
+	return false
+}
+
+func compareResult(op string, cmp int) bool {
+	switch op {
+	case OpLess, OpVersionLess:
+		return cmp < 0
+	case OpLessOrEqual, OpVersionLessOrEqual:
+		return cmp <= 0
+	case OpGreater, OpVersionGreater:
+		return cmp > 0
+	case OpGreaterOrEqual, OpVersionGreaterOrEqual:
+		return cmp >= 0
+	}
+	return false
+}
+
+// compareValues compares numerically when both sides are numbers and
+// lexicographically otherwise.
+func compareValues(a, b string) int {
+	af, aErr := strconv.ParseFloat(a, 64)
+	bf, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// compareVersions compares dotted numeric versions such as "1.10.2". Any
+// pre-release or build suffix is ignored.
+func compareVersions(a, b string) (int, bool) {
+	av, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	bv, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := 0; i < len(av) || i < len(bv); i++ {
+		var x, y int
+		if i < len(av) {
+			x = av[i]
+		}
+		if i < len(bv) {
+			y = bv[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	if s == "" {
+		return nil, false
+	}
+	parts := strings.Split(s, ".")
+	version := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+func isNone(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []interface{}:
+		return len(value) == 0
+	case []string:
+		return len(value) == 0
+	}
+	return false
+}
+
+func stringValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func stringValues(v interface{}) []string {
+	switch value := v.(type) {
+	case []string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, e := range value {
+			values = append(values, stringValue(e))
+		}
+		return values
+	}
+	return []string{stringValue(v)}
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(s, values []string) bool {
+	for _, v := range values {
+		if !contains(s, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(s, values []string) bool {
+	for _, v := range values {
+		if contains(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSet(a, b []string) bool {
+	return containsAll(a, b) && containsAll(b, a)
+}
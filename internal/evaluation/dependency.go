@@ -0,0 +1,113 @@
+package evaluation
+
+import (
+	"fmt"
+	"strings"
+)
+
+const dependencyOperatorAny = "any"
+
+// DependencyCycleError reports flags whose prerequisites depend on
+// themselves. FlagKeys lists the cycle, starting and ending with the same key.
+type DependencyCycleError struct {
+	FlagKeys []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("flag dependency cycle: %s", strings.Join(e.FlagKeys, " -> "))
+}
+
+// SortFlags orders flags so that every prerequisite precedes its dependents,
+// keeping the original order otherwise. Prerequisites missing from flags are
+// ignored here and treated as unsatisfied during evaluation.
+func SortFlags(flags []*Flag) ([]*Flag, error) {
+	index := make(map[string]*Flag, len(flags))
+	for _, flag := range flags {
+		index[flag.Key] = flag
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(flags))
+	sorted := make([]*Flag, 0, len(flags))
+	var path []string
+	var visit func(flag *Flag) error
+	visit = func(flag *Flag) error {
+		switch state[flag.Key] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, key := range path {
+				if key == flag.Key {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), flag.Key)
+			return &DependencyCycleError{FlagKeys: cycle}
+		}
+		state[flag.Key] = visiting
+		path = append(path, flag.Key)
+		for _, key := range flag.Parents() {
+			if parent := index[key]; parent != nil {
+				if err := visit(parent); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[flag.Key] = visited
+		sorted = append(sorted, flag)
+		return nil
+	}
+	for _, flag := range flags {
+		if err := visit(flag); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// checkDependencies reports whether the flag's prerequisites are satisfied
+// by the results evaluated so far, along with the transitive dependency chain
+// and the first unsatisfied prerequisite.
+func checkDependencies(flag *Flag, results map[string]Result) (bool, []string, string) {
+	parents := flag.Parents()
+	if len(parents) == 0 {
+		return true, nil, ""
+	}
+	var chain []string
+	seen := make(map[string]bool)
+	for _, key := range parents {
+		for _, dep := range results[key].Dependencies {
+			if !seen[dep] {
+				seen[dep] = true
+				chain = append(chain, dep)
+			}
+		}
+		if !seen[key] {
+			seen[key] = true
+			chain = append(chain, key)
+		}
+	}
+	matchAny := strings.EqualFold(flag.ParentDependencies.Operator, dependencyOperatorAny)
+	failed := ""
+	for _, key := range parents {
+		result, ok := results[key]
+		satisfied := ok && contains(flag.ParentDependencies.Flags[key], result.Variant.Key)
+		if satisfied && matchAny {
+			return true, chain, ""
+		}
+		if !satisfied && failed == "" {
+			failed = key
+			if !matchAny {
+				break
+			}
+		}
+	}
+	if failed != "" {
+		return false, chain, failed
+	}
+	return true, chain, ""
+}
@@ -0,0 +1,213 @@
+package evaluation
+
+import (
+	"strings"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+const (
+	// percentageBuckets is the resolution of Allocation.Percentage.
+	percentageBuckets = 10000
+	// distributionBuckets is the number of distinct distribution values a
+	// 32-bit hash yields once the allocation bucket has been taken out.
+	distributionBuckets = (1<<32-1)/percentageBuckets + 1
+)
+
+// Reasons reported on Result by the Go engine.
+const (
+	ReasonTargeted = "targeted"
+	ReasonRollout  = "rollout"
+	ReasonDefault  = "default"
+	ReasonFlagOff  = "flag-off"
+	// ReasonPrerequisiteFailed means a prerequisite flag did not evaluate to
+	// a required variant, so the default variant was served.
+	ReasonPrerequisiteFailed = "prerequisite-failed"
+)
+
+const (
+	descriptionFlagDisabled       = "flag-disabled"
+	descriptionPrerequisiteFailed = "prerequisite-failed"
+	descriptionInclusionList      = "inclusion-list"
+	descriptionExclusionList      = "exclusion-list"
+	descriptionSegmentMatch       = "segment-match"
+	descriptionAllUsers           = "all-users"
+	descriptionNotBucketed        = "not-bucketed"
+	descriptionNoMatch            = "no-match"
+)
+
+// ReasonForDescription maps the description of a Result to a reason. It
+// recognises the descriptions of the Go engine; whether the interop library
+// uses the same ones is unverified. It returns "" when the description is not
+// recognised.
+func ReasonForDescription(description string) string {
+	switch d := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(description)), " ", "-"); {
+	case d == descriptionFlagDisabled:
+		return ReasonFlagOff
+	case strings.HasPrefix(d, descriptionPrerequisiteFailed):
+		return ReasonPrerequisiteFailed
+	case d == descriptionInclusionList, d == descriptionSegmentMatch:
+		return ReasonTargeted
+	case d == descriptionAllUsers:
+		return ReasonRollout
+	case d == descriptionExclusionList, d == descriptionNotBucketed, d == descriptionNoMatch:
+		return ReasonDefault
+	}
+	return ""
+}
+
+// EvaluateFlags is the pure-Go counterpart of Evaluate. It evaluates flags
+// for user without crossing into the interop library. Flags must be ordered
+// with prerequisites first, as returned by SortFlags, and should have been
+// passed to Prepare.
+//
+// Users are bucketed by the murmur3 hash of "salt/value": the hash modulo
+// 10000 selects the allocation and the hash divided by 10000 selects the
+// variant by weight. This split has not been verified against the interop
+// library, and the settings listed by Flag.UnsupportedFields are ignored, so
+// results may differ from EvaluationEngineInterop; see parity_test.go.
+func EvaluateFlags(flags []*Flag, user *experiment.User) map[string]Result {
+	t := newTarget(user)
+	results := make(map[string]Result, len(flags))
+	for _, flag := range flags {
+		if flag == nil {
+			continue
+		}
+		results[flag.Key] = evaluateFlag(flag, t, results)
+	}
+	return results
+}
+
+func evaluateFlag(flag *Flag, t *target, results map[string]Result) Result {
+	if !flag.Enabled {
+		return defaultResult(flag, ReasonFlagOff, descriptionFlagDisabled)
+	}
+	satisfied, chain, failed := checkDependencies(flag, results)
+	if !satisfied {
+		result := defaultResult(flag, ReasonPrerequisiteFailed, descriptionPrerequisiteFailed+": "+failed)
+		result.Dependencies = chain
+		return result
+	}
+	result := evaluateTargeting(flag, t)
+	result.Dependencies = chain
+	return result
+}
+
+func evaluateTargeting(flag *Flag, t *target) Result {
+	if v := flag.listedVariant(flag.VariantsInclusions, t); v != nil {
+		return Result{
+			Variant:      *v,
+			Description:  descriptionInclusionList,
+			Reason:       ReasonTargeted,
+			SegmentIndex: -1,
+			Bucket:       -1,
+		}
+	}
+	for i, segment := range flag.CustomSegmentTargetingConfigs {
+		if segment == nil || !matchConditions(segment.Conditions, t) {
+			continue
+		}
+		return allocate(flag, segment, i, t, ReasonTargeted, descriptionSegmentMatch)
+	}
+	if flag.AllUsersTargetingConfig != nil && matchConditions(flag.AllUsersTargetingConfig.Conditions, t) {
+		index := len(flag.CustomSegmentTargetingConfigs)
+		return allocate(flag, flag.AllUsersTargetingConfig, index, t, ReasonRollout, descriptionAllUsers)
+	}
+	return defaultResult(flag, ReasonDefault, descriptionNoMatch)
+}
+
+// allocate buckets the user within a matched segment. Users outside every
+// allocation, or excluded from the variant they land in, get the default.
+func allocate(flag *Flag, segment *Segment, index int, t *target, reason, description string) Result {
+	result := defaultResult(flag, ReasonDefault, descriptionNotBucketed)
+	result.Segment = segment.Name
+	result.SegmentIndex = index
+	bucketingKey := segment.BucketingKey
+	if bucketingKey == "" {
+		bucketingKey = flag.BucketingKey
+	}
+	value := t.bucketingValue(bucketingKey)
+	if value == "" {
+		return result
+	}
+	hash := murmur3([]byte(flag.BucketingSalt+"/"+value), 0)
+	bucket := int(hash % percentageBuckets)
+	distribution := uint64(hash / percentageBuckets)
+	result.Bucket = bucket
+	threshold := 0
+	for _, allocation := range segment.Allocations {
+		if allocation == nil {
+			continue
+		}
+		threshold += allocation.Percentage
+		if bucket >= threshold {
+			continue
+		}
+		v := flag.weightedVariant(allocation, distribution)
+		if v == nil {
+			break
+		}
+		if t.listedIn(flag.VariantsExclusions[v.Key]) {
+			result.Description = descriptionExclusionList
+			return result
+		}
+		result.Variant = *v
+		result.Description = description
+		result.Reason = reason
+		result.IsDefaultVariant = false
+		return result
+	}
+	return result
+}
+
+// weightedVariant picks a variant by walking the flag's variants in order and
+// comparing the distribution value against cumulative weight ranges.
+func (f *Flag) weightedVariant(allocation *Allocation, distribution uint64) *Variant {
+	total := 0
+	for _, v := range f.Variants {
+		if v == nil {
+			continue
+		}
+		if weight := allocation.Weights[v.Key]; weight > 0 {
+			total += weight
+		}
+	}
+	if total <= 0 {
+		return nil
+	}
+	cumulative := 0
+	for _, v := range f.Variants {
+		if v == nil {
+			continue
+		}
+		weight := allocation.Weights[v.Key]
+		if weight <= 0 {
+			continue
+		}
+		cumulative += weight
+		if distribution*uint64(total) < uint64(cumulative)*distributionBuckets {
+			return v
+		}
+	}
+	return nil
+}
+
+func (f *Flag) listedVariant(lists map[string][]string, t *target) *Variant {
+	for _, v := range f.Variants {
+		if v != nil && t.listedIn(lists[v.Key]) {
+			return v
+		}
+	}
+	return nil
+}
+
+func defaultResult(flag *Flag, reason, description string) Result {
+	return Result{
+		Variant:          flag.DefaultVariant(),
+		Description:      description,
+		IsDefaultVariant: true,
+		Reason:           reason,
+		SegmentIndex:     -1,
+		Bucket:           -1,
+	}
+}
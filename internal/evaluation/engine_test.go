@@ -0,0 +1,294 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+func TestMurmur3(t *testing.T) {
+	// Reference values of MurmurHash3 x86_32 with seed 0.
+	tests := []struct {
+		input string
+		want  uint32
+	}{
+		{"", 0},
+		{"hello", 0x248bfa47},
+		{"Hello, world!", 0xc0363e43},
+		{"The quick brown fox jumps over the lazy dog", 0x2e4ff723},
+	}
+	for _, tt := range tests {
+		if got := murmur3([]byte(tt.input), 0); got != tt.want {
+			t.Errorf("murmur3(%q) = %#x, want %#x", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMatchCondition(t *testing.T) {
+	user := &experiment.User{
+		UserId:  "user-1",
+		Country: "India",
+		Version: "1.10.2",
+		UserProperties: map[string]interface{}{
+			"plan":  "enterprise",
+			"seats": float64(25),
+			"tags":  []interface{}{"beta", "internal"},
+		},
+	}
+	tests := []struct {
+		prop   string
+		op     string
+		values []string
+		want   bool
+	}{
+		{"country", OpIs, []string{"India", "Japan"}, true},
+		{"country", OpIs, []string{"india"}, false},
+		{"country", OpIsNot, []string{"Japan"}, true},
+		{"gp:plan", OpIs, []string{"enterprise"}, true},
+		{"plan", OpIs, []string{"enterprise"}, true},
+		{"gp:plan", OpContains, []string{"PRISE"}, true},
+		{"gp:plan", OpDoesNotContain, []string{"free"}, true},
+		{"gp:seats", OpGreater, []string{"9"}, true},
+		{"gp:seats", OpLessOrEqual, []string{"25"}, true},
+		{"gp:seats", OpLess, []string{"25"}, false},
+		{"version", OpVersionGreater, []string{"1.9"}, true},
+		{"version", OpVersionLess, []string{"1.10.2"}, false},
+		{"version", OpVersionGreaterOrEqual, []string{"1.10.2"}, true},
+		{"gp:tags", OpIs, []string{"beta"}, true},
+		{"gp:tags", OpSetIs, []string{"internal", "beta"}, true},
+		{"gp:tags", OpSetContains, []string{"beta"}, true},
+		{"gp:tags", OpSetContainsAny, []string{"alpha", "internal"}, true},
+		{"gp:tags", OpSetDoesNotContainAny, []string{"alpha"}, true},
+		{"gp:tags", OpSetIsNot, []string{"beta"}, true},
+		{"gp:plan", OpRegexMatch, []string{"^ent.*se$"}, true},
+		{"gp:plan", OpRegexMatch, []string{"("}, false},
+		{"gp:plan", OpRegexDoesNotMatch, []string{"^free"}, true},
+		{"gp:missing", OpIs, []string{"(none)"}, true},
+		{"gp:missing", OpIs, []string{"x"}, false},
+		{"gp:missing", OpIsNot, []string{"x"}, true},
+		{"gp:missing", OpDoesNotContain, []string{"x"}, true},
+		{"gp:missing", OpGreater, []string{"1"}, false},
+		{"country", "is not", []string{"Japan"}, true},
+	}
+	for _, tt := range tests {
+		c := &Condition{Prop: tt.prop, Op: tt.op, Values: tt.values}
+		if got := matchCondition(c, newTarget(user)); got != tt.want {
+			t.Errorf("%s %s %v = %v, want %v", tt.prop, tt.op, tt.values, got, tt.want)
+		}
+		(&Segment{Conditions: []*Condition{c}}).prepare()
+		if got := matchCondition(c, newTarget(user)); got != tt.want {
+			t.Errorf("prepared %s %s %v = %v, want %v", tt.prop, tt.op, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateFlags(t *testing.T) {
+	on := "on"
+	flags := []*Flag{
+		{
+			Key:           "off",
+			Enabled:       false,
+			DefaultValue:  &on,
+			Variants:      []*Variant{{Key: "on"}},
+			BucketingSalt: "salt",
+		},
+		{
+			Key:                "targeted",
+			Enabled:            true,
+			BucketingSalt:      "salt",
+			Variants:           []*Variant{{Key: "on", Payload: "p"}, {Key: "off"}},
+			VariantsInclusions: map[string][]string{"on": {"included"}},
+			VariantsExclusions: map[string][]string{"on": {"excluded"}},
+			CustomSegmentTargetingConfigs: []*Segment{{
+				Name:        "enterprise",
+				Conditions:  []*Condition{{Prop: "gp:plan", Op: OpIs, Values: []string{"enterprise"}}},
+				Allocations: []*Allocation{{Percentage: 10000, Weights: map[string]int{"on": 1}}},
+			}},
+		},
+		{
+			Key:           "rollout",
+			Enabled:       true,
+			BucketingSalt: "salt",
+			Variants:      []*Variant{{Key: "control"}, {Key: "treatment"}},
+			AllUsersTargetingConfig: &Segment{
+				Name:        "all",
+				Allocations: []*Allocation{{Percentage: 5000, Weights: map[string]int{"control": 1, "treatment": 1}}},
+			},
+		},
+		{
+			Key:                "dependent",
+			Enabled:            true,
+			Variants:           []*Variant{{Key: "on"}},
+			ParentDependencies: &ParentDependencies{Flags: map[string][]string{"targeted": {"on"}}},
+			AllUsersTargetingConfig: &Segment{
+				Allocations: []*Allocation{{Percentage: 10000, Weights: map[string]int{"on": 1}}},
+			},
+		},
+	}
+	Prepare(flags)
+	type want struct {
+		variant string
+		reason  string
+		bucket  int
+	}
+	// The rollout results pin the Go engine's own bucketing to catch
+	// regressions; TestInteropResults checks it against the interop library.
+	tests := []struct {
+		user *experiment.User
+		want map[string]want
+	}{
+		{
+			user: &experiment.User{UserId: "user-1"},
+			want: map[string]want{
+				"off":       {"on", ReasonFlagOff, -1},
+				"targeted":  {"off", ReasonDefault, -1},
+				"rollout":   {"control", ReasonRollout, 538},
+				"dependent": {"off", ReasonPrerequisiteFailed, -1},
+			},
+		},
+		{
+			user: &experiment.User{UserId: "user-2"},
+			want: map[string]want{
+				"rollout": {"off", ReasonDefault, 5883},
+			},
+		},
+		{
+			user: &experiment.User{UserId: "included"},
+			want: map[string]want{
+				"targeted":  {"on", ReasonTargeted, -1},
+				"dependent": {"on", ReasonRollout, -1},
+			},
+		},
+		{
+			user: &experiment.User{UserId: "excluded", UserProperties: map[string]interface{}{"plan": "enterprise"}},
+			want: map[string]want{
+				"targeted": {"off", ReasonDefault, -1},
+			},
+		},
+		{
+			user: &experiment.User{UserId: "user-3", UserProperties: map[string]interface{}{"plan": "enterprise"}},
+			want: map[string]want{
+				"targeted":  {"on", ReasonTargeted, -1},
+				"dependent": {"on", ReasonRollout, -1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		results := EvaluateFlags(flags, tt.user)
+		for key, w := range tt.want {
+			r := results[key]
+			if got := ReasonForDescription(r.Description); got != r.Reason {
+				t.Errorf("ReasonForDescription(%q) = %q, want %q", r.Description, got, r.Reason)
+			}
+			if r.Variant.Key != w.variant || r.Reason != w.reason || (w.bucket != -1 && r.Bucket != w.bucket) {
+				t.Errorf("%s for %s = %s %s %d, want %s %s %d", key, tt.user.UserId,
+					r.Variant.Key, r.Reason, r.Bucket, w.variant, w.reason, w.bucket)
+			}
+		}
+	}
+}
+
+func TestUnsupportedFields(t *testing.T) {
+	flags, err := parseFlagsForTest(`[
+		{"flagKey":"a","enabled":true,"globalHoldbackPct":5,"mutualExclusionConfig":{"groupSalt":"g"}},
+		{"flagKey":"b","enabled":true,"mutualExclusionConfig":null}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := flags[0].UnsupportedFields(); len(got) != 2 {
+		t.Errorf("UnsupportedFields(a) = %v", got)
+	}
+	if got := flags[1].UnsupportedFields(); len(got) != 0 {
+		t.Errorf("UnsupportedFields(b) = %v", got)
+	}
+}
+
+func TestReasonForDescription(t *testing.T) {
+	tests := map[string]string{
+		"flag-disabled":                      ReasonFlagOff,
+		"Flag Disabled":                      ReasonFlagOff,
+		"prerequisite-failed: parent":        ReasonPrerequisiteFailed,
+		"inclusion-list":                     ReasonTargeted,
+		"all-users":                          ReasonRollout,
+		"exclusion-list":                     ReasonDefault,
+		"something the engine never returns": "",
+		"":                                   "",
+	}
+	for description, want := range tests {
+		if got := ReasonForDescription(description); got != want {
+			t.Errorf("ReasonForDescription(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
+
+func TestEvaluateFlagsNullEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		variant string
+	}{
+		{
+			name:    "null condition",
+			flag:    `{"flagKey":"f","enabled":true,"bucketingSalt":"s","variants":[{"key":"on"}],"allUsersTargetingConfig":{"conditions":[null],"allocations":[{"percentage":10000,"weights":{"on":1}}]}}`,
+			variant: "on",
+		},
+		{
+			name:    "null variant in allocation",
+			flag:    `{"flagKey":"f","enabled":true,"bucketingSalt":"s","variants":[null,{"key":"on"}],"allUsersTargetingConfig":{"allocations":[{"percentage":10000,"weights":{"on":1}}]}}`,
+			variant: "on",
+		},
+		{
+			name:    "null variant in inclusion list",
+			flag:    `{"flagKey":"f","enabled":true,"variants":[null,{"key":"on"}],"variantsInclusions":{"on":["user"]}}`,
+			variant: "on",
+		},
+		{
+			name:    "null variant in disabled flag",
+			flag:    `{"flagKey":"f","enabled":false,"defaultValue":"on","variants":[null]}`,
+			variant: "on",
+		},
+		{
+			name:    "null variant in exclusion list",
+			flag:    `{"flagKey":"f","enabled":true,"bucketingSalt":"s","variants":[null,{"key":"on"}],"variantsExclusions":{"on":["user"]},"allUsersTargetingConfig":{"allocations":[{"percentage":10000,"weights":{"on":1}}]}}`,
+			variant: "off",
+		},
+	}
+	for _, tt := range tests {
+		flags, err := parseFlagsForTest("[" + tt.flag + "]")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		Prepare(flags)
+		result := EvaluateFlags(flags, &experiment.User{UserId: "user"})["f"]
+		if result.Variant.Key != tt.variant {
+			t.Errorf("%s: variant %q, want %q", tt.name, result.Variant.Key, tt.variant)
+		}
+	}
+}
+
+// TestInteropResults compares EvaluateFlags with the interop library's
+// recorded results, without needing cgo. It is skipped until the results are
+// recorded, see TestInteropParity.
+func TestInteropResults(t *testing.T) {
+	data, err := os.ReadFile(interopResultsPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("no recorded interop results, run with EVALUATION_INTEROP_PARITY=record")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []interopCase
+	err = json.Unmarshal(data, &cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, flags := parityFlags(t)
+	for _, c := range cases {
+		compareResults(t, c.User, EvaluateFlags(flags, c.User), c.Results)
+	}
+}
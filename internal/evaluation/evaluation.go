@@ -1,3 +1,5 @@
+//go:build cgo
+
 package evaluation
 
 /*
@@ -34,6 +36,10 @@ import (
 	"unsafe"
 )
 
+// InteropAvailable reports whether the Kotlin/Native evaluation library is
+// linked into this build.
+const InteropAvailable = true
+
 var lib = C.libevaluation_interop_symbols()
 var root = lib.kotlin.root
 
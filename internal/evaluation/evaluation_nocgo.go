@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package evaluation
+
+// InteropAvailable reports whether the Kotlin/Native evaluation library is
+// linked into this build.
+const InteropAvailable = false
+
+// Evaluate mirrors the cgo bridge so callers compile without cgo. It always
+// reports an error; use EvaluateFlags instead.
+func Evaluate(rules, user string) string {
+	return `{"error":"evaluation interop library is not available: built with CGO_ENABLED=0"}`
+}
@@ -0,0 +1,205 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultVariantKey is served when a flag does not define a default value.
+const DefaultVariantKey = "off"
+
+// Flag is a single flag configuration as served by sdk/v1/flags.
+type Flag struct {
+	Key                           string              `json:"flagKey"`
+	Name                          string              `json:"flagName,omitempty"`
+	Version                       int                 `json:"flagVersion,omitempty"`
+	Type                          string              `json:"type,omitempty"`
+	EvalMode                      string              `json:"evalMode,omitempty"`
+	Deployed                      bool                `json:"deployed,omitempty"`
+	UserProperty                  string              `json:"userProperty,omitempty"`
+	Enabled                       bool                `json:"enabled"`
+	Description                   string              `json:"description,omitempty"`
+	BucketingKey                  string              `json:"bucketingKey,omitempty"`
+	BucketingSalt                 string              `json:"bucketingSalt,omitempty"`
+	DefaultValue                  *string             `json:"defaultValue,omitempty"`
+	Variants                      []*Variant          `json:"variants,omitempty"`
+	AllUsersTargetingConfig       *Segment            `json:"allUsersTargetingConfig,omitempty"`
+	CustomSegmentTargetingConfigs []*Segment          `json:"customSegmentTargetingConfigs,omitempty"`
+	VariantsInclusions            map[string][]string `json:"variantsInclusions,omitempty"`
+	VariantsExclusions            map[string][]string `json:"variantsExclusions,omitempty"`
+	ParentDependencies            *ParentDependencies `json:"parentDependencies,omitempty"`
+
+	// The global holdback and mutual exclusion settings are decoded so that
+	// they can be reported, but the Go engine does not apply them, see
+	// UnsupportedFields.
+	GlobalHoldbackPct          float64         `json:"globalHoldbackPct,omitempty"`
+	GlobalHoldbackSalt         string          `json:"globalHoldbackSalt,omitempty"`
+	GlobalHoldbackBucketingKey string          `json:"globalHoldbackBucketingKey,omitempty"`
+	MutualExclusionConfig      json.RawMessage `json:"mutualExclusionConfig,omitempty"`
+}
+
+// ParentDependencies makes a flag a dependent of other flags. Flags maps each
+// prerequisite flag key to the variants that satisfy it; Operator is "all"
+// (the default) or "any".
+type ParentDependencies struct {
+	Flags    map[string][]string `json:"flags"`
+	Operator string              `json:"operator,omitempty"`
+}
+
+type Variant struct {
+	Key     string      `json:"key"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+type Segment struct {
+	Name         string        `json:"name,omitempty"`
+	BucketingKey string        `json:"bucketingKey,omitempty"`
+	Conditions   []*Condition  `json:"conditions,omitempty"`
+	Allocations  []*Allocation `json:"allocations,omitempty"`
+}
+
+type Condition struct {
+	Prop   string   `json:"prop"`
+	Op     string   `json:"op"`
+	Values []string `json:"values,omitempty"`
+
+	// regexps holds the compiled Values of a regex condition, nil for an
+	// invalid pattern, once Prepare has run.
+	regexps []*regexp.Regexp
+}
+
+// Allocation assigns Percentage basis points (0-10000) of the bucketed
+// population to the flag's variants in proportion to Weights.
+type Allocation struct {
+	Percentage int            `json:"percentage"`
+	Weights    map[string]int `json:"weights,omitempty"`
+}
+
+// Result is the outcome of evaluating one flag for one user. Its JSON form
+// matches the per-flag result returned by the interop library; the remaining
+// fields are only filled in by the Go engine.
+type Result struct {
+	Variant          Variant `json:"variant"`
+	Description      string  `json:"description,omitempty"`
+	IsDefaultVariant bool    `json:"isDefaultVariant,omitempty"`
+
+	Reason string `json:"-"`
+	// Segment and SegmentIndex identify the matched segment. Custom segments
+	// are numbered from 0 and the all-users segment follows them; -1 means no
+	// segment matched.
+	Segment      string `json:"-"`
+	SegmentIndex int    `json:"-"`
+	// Bucket is the user's allocation bucket in [0, 10000), or -1 if the user
+	// was not bucketed.
+	Bucket int `json:"-"`
+	// Dependencies lists the prerequisite flags checked, transitively, in
+	// evaluation order.
+	Dependencies []string `json:"-"`
+}
+
+// ChangedFields returns the JSON names of the fields that differ between two
+// configurations of the same flag. Fields are compared by their JSON
+// encoding, so state derived by Prepare is ignored.
+func ChangedFields(a, b *Flag) []string {
+	var fields []string
+	av := reflect.ValueOf(a).Elem()
+	bv := reflect.ValueOf(b).Elem()
+	t := av.Type()
+	for i := 0; i < t.NumField(); i++ {
+		aj, aErr := json.Marshal(av.Field(i).Interface())
+		bj, bErr := json.Marshal(bv.Field(i).Interface())
+		if aErr == nil && bErr == nil && bytes.Equal(aj, bj) {
+			continue
+		}
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// Prepare readies flags for EvaluateFlags by compiling the regular
+// expressions of their conditions once. It must run before the flags are
+// shared between goroutines.
+func Prepare(flags []*Flag) {
+	for _, flag := range flags {
+		if flag == nil {
+			continue
+		}
+		if flag.AllUsersTargetingConfig != nil {
+			flag.AllUsersTargetingConfig.prepare()
+		}
+		for _, segment := range flag.CustomSegmentTargetingConfigs {
+			if segment != nil {
+				segment.prepare()
+			}
+		}
+	}
+}
+
+func (s *Segment) prepare() {
+	for _, c := range s.Conditions {
+		if c == nil {
+			continue
+		}
+		switch normalizeOp(c.Op) {
+		case OpRegexMatch, OpRegexDoesNotMatch:
+			c.regexps = make([]*regexp.Regexp, len(c.Values))
+			for i, v := range c.Values {
+				c.regexps[i], _ = regexp.Compile(v)
+			}
+		}
+	}
+}
+
+// UnsupportedFields returns the JSON names of the flag's settings that the Go
+// engine does not apply: the global holdback and mutual exclusion. A flag
+// using them may evaluate differently than with the interop engine.
+func (f *Flag) UnsupportedFields() []string {
+	var fields []string
+	if f.GlobalHoldbackPct != 0 {
+		fields = append(fields, "globalHoldbackPct")
+	}
+	if len(f.MutualExclusionConfig) != 0 && string(f.MutualExclusionConfig) != "null" {
+		fields = append(fields, "mutualExclusionConfig")
+	}
+	return fields
+}
+
+// Parents returns the keys of the flag's direct prerequisites in a stable
+// order.
+func (f *Flag) Parents() []string {
+	if f.ParentDependencies == nil || len(f.ParentDependencies.Flags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(f.ParentDependencies.Flags))
+	for key := range f.ParentDependencies.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *Flag) variant(key string) *Variant {
+	for _, v := range f.Variants {
+		if v != nil && v.Key == key {
+			return v
+		}
+	}
+	return nil
+}
+
+// DefaultVariant returns the variant served when no segment assigns one.
+func (f *Flag) DefaultVariant() Variant {
+	key := DefaultVariantKey
+	if f.DefaultValue != nil && *f.DefaultValue != "" {
+		key = *f.DefaultValue
+	}
+	if v := f.variant(key); v != nil {
+		return *v
+	}
+	return Variant{Key: key}
+}
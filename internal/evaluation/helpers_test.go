@@ -0,0 +1,72 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// interopResultsPath holds the interop library's results for parityUsers on
+// testdata/flags.json, recorded by TestInteropParity with
+// EVALUATION_INTEROP_PARITY=record.
+const interopResultsPath = "testdata/interop_results.json"
+
+// interopCase is one user's recorded interop results, keyed by flag key.
+type interopCase struct {
+	User    *experiment.User  `json:"user"`
+	Results map[string]Result `json:"results"`
+}
+
+func parseFlagsForTest(data string) ([]*Flag, error) {
+	var flags []*Flag
+	err := json.Unmarshal([]byte(data), &flags)
+	return flags, err
+}
+
+// parityFlags returns testdata/flags.json, raw and sorted for EvaluateFlags.
+func parityFlags(t *testing.T) (string, []*Flag) {
+	rules, err := os.ReadFile("testdata/flags.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, err := parseFlagsForTest(string(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted, err := SortFlags(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Prepare(sorted)
+	return string(rules), sorted
+}
+
+// parityUsers returns the users the engines are compared on.
+func parityUsers() []*experiment.User {
+	plans := []string{"free", "team", "enterprise"}
+	users := make([]*experiment.User, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		users = append(users, &experiment.User{
+			UserId:         fmt.Sprintf("user-%d", i),
+			DeviceId:       fmt.Sprintf("device-%d", i%7),
+			UserProperties: map[string]interface{}{"plan": plans[i%3]},
+		})
+	}
+	return users
+}
+
+// compareResults reports the flags for which got differs from the interop
+// results in want.
+func compareResults(t *testing.T, user *experiment.User, got, want map[string]Result) {
+	t.Helper()
+	for key, w := range want {
+		g := got[key]
+		if g.Variant.Key != w.Variant.Key || g.IsDefaultVariant != w.IsDefaultVariant {
+			t.Errorf("%s for %s: go %s (default %v), interop %s (default %v)", key, user.UserId,
+				g.Variant.Key, g.IsDefaultVariant, w.Variant.Key, w.IsDefaultVariant)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+//go:build cgo
+
+package evaluation
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestInteropParity compares EvaluateFlags with the interop library on
+// testdata/flags.json. It needs the real library rather than one built for
+// linking, so it only runs with EVALUATION_INTEROP_PARITY=1, or with
+// EVALUATION_INTEROP_PARITY=record, which also records the interop results
+// for TestInteropResults.
+func TestInteropParity(t *testing.T) {
+	mode := os.Getenv("EVALUATION_INTEROP_PARITY")
+	if mode != "1" && mode != "record" {
+		t.Skip("set EVALUATION_INTEROP_PARITY=1 to compare with the interop library")
+	}
+	rules, flags := parityFlags(t)
+	var cases []interopCase
+	for _, user := range parityUsers() {
+		userJSON, err := json.Marshal(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var interop struct {
+			Result map[string]Result `json:"result"`
+			Error  string            `json:"error"`
+		}
+		err = json.Unmarshal([]byte(Evaluate(rules, string(userJSON))), &interop)
+		if err != nil || interop.Error != "" {
+			t.Fatalf("interop evaluation failed: %v %s", err, interop.Error)
+		}
+		compareResults(t, user, EvaluateFlags(flags, user), interop.Result)
+		cases = append(cases, interopCase{User: user, Results: interop.Result})
+	}
+	if mode != "record" {
+		return
+	}
+	data, err := json.MarshalIndent(cases, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(interopResultsPath, append(data, '\n'), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
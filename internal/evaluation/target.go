@@ -0,0 +1,98 @@
+package evaluation
+
+import (
+	"strings"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+const userPropertyPrefix = "gp:"
+
+// target resolves condition and bucketing properties against a user.
+type target struct {
+	user *experiment.User
+}
+
+func newTarget(user *experiment.User) *target {
+	if user == nil {
+		user = &experiment.User{}
+	}
+	return &target{user: user}
+}
+
+// value returns the user's value for prop, or nil if it is unset. Custom
+// properties may be referenced either as "gp:<name>" or by bare name.
+func (t *target) value(prop string) interface{} {
+	if strings.HasPrefix(prop, userPropertyPrefix) {
+		return t.property(strings.TrimPrefix(prop, userPropertyPrefix))
+	}
+	if v := t.field(prop); v != "" {
+		return v
+	}
+	return t.property(prop)
+}
+
+func (t *target) property(name string) interface{} {
+	if t.user.UserProperties == nil {
+		return nil
+	}
+	return t.user.UserProperties[name]
+}
+
+func (t *target) field(name string) string {
+	u := t.user
+	switch name {
+	case "user_id":
+		return u.UserId
+	case "device_id":
+		return u.DeviceId
+	case "country":
+		return u.Country
+	case "region":
+		return u.Region
+	case "dma":
+		return u.Dma
+	case "city":
+		return u.City
+	case "language":
+		return u.Language
+	case "platform":
+		return u.Platform
+	case "version":
+		return u.Version
+	case "os":
+		return u.Os
+	case "device_manufacturer":
+		return u.DeviceManufacturer
+	case "device_brand":
+		return u.DeviceBrand
+	case "device_model":
+		return u.DeviceModel
+	case "carrier":
+		return u.Carrier
+	case "library":
+		return u.Library
+	}
+	return ""
+}
+
+// bucketingValue returns the string used to place the user into a bucket.
+// Without an explicit key the device id is used, falling back to the user id.
+func (t *target) bucketingValue(key string) string {
+	if key != "" {
+		return stringValue(t.value(key))
+	}
+	if t.user.DeviceId != "" {
+		return t.user.DeviceId
+	}
+	return t.user.UserId
+}
+
+// listedIn reports whether the user's id or device id appears in ids.
+func (t *target) listedIn(ids []string) bool {
+	if len(ids) == 0 {
+		return false
+	}
+	return (t.user.UserId != "" && contains(ids, t.user.UserId)) ||
+		(t.user.DeviceId != "" && contains(ids, t.user.DeviceId))
+}
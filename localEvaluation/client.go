@@ -0,0 +1,204 @@
+package localEvaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment/local"
+	"github.com/joho/godotenv"
+)
+
+type Options struct {
+	DeploymentKey        string
+	ServerUrl            string
+	Debug                bool
+	PollInterval         time.Duration
+	PollerRequestTimeout time.Duration
+	EvaluationEngine     local.EvaluationEngine
+	// StartInBackground makes New return without waiting for the first flag
+	// config. Flags read before it is loaded return their defaults.
+	StartInBackground bool
+	// FlagConfigPath evaluates flags from a file or directory of flag
+	// configs instead of the server, see local.Config.FlagConfigPath.
+	FlagConfigPath string
+	// HTTPClient and HTTP configure the HTTP client, see local.Config.
+	HTTPClient *http.Client
+	HTTP       *experiment.HTTPConfig
+}
+
+// DefaultOptions returns options built from the LocalEvaluation* variables.
+func DefaultOptions() Options {
+	return Options{
+		DeploymentKey:        LocalEvaluationDeploymentKey,
+		ServerUrl:            LocalEvaluationConfigServerUrl,
+		Debug:                LocalEvaluationConfigDebug,
+		PollInterval:         time.Duration(LocalEvaluationConfigPollInterval) * time.Second,
+		PollerRequestTimeout: time.Duration(LocalEvaluationConfigPollerRequestTimeout) * time.Second,
+		EvaluationEngine:     evaluationEngine(LocalEvaluationConfigEngine),
+		FlagConfigPath:       LocalEvaluationConfigFlagConfigPath,
+		StartInBackground:    LocalEvaluationConfigStartInBackground,
+	}
+}
+
+// OptionsFromEnv returns DefaultOptions overridden by the current
+// LOCAL_EVALUATION_* environment variables, loading a .env file first if one
+// exists. Unlike the package's own reading of the environment, it reports
+// malformed values. Initialize does not use it, so that the LocalEvaluation*
+// variables take precedence.
+func OptionsFromEnv() (Options, error) {
+	_ = godotenv.Load()
+	options := DefaultOptions()
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_DEBUG"); v != "" {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return options, fmt.Errorf("LOCAL_EVALUATION_CONFIG_DEBUG: %w", err)
+		}
+		options.Debug = debug
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_SERVER_URL"); v != "" {
+		options.ServerUrl = v
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_POLL_INTERVAL"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return options, fmt.Errorf("LOCAL_EVALUATION_CONFIG_POLL_INTERVAL: %w", err)
+		}
+		options.PollInterval = time.Duration(seconds) * time.Second
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_POLLER_REQUEST_TIMEOUT"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil {
+			return options, fmt.Errorf("LOCAL_EVALUATION_CONFIG_POLLER_REQUEST_TIMEOUT: %w", err)
+		}
+		options.PollerRequestTimeout = time.Duration(seconds) * time.Second
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_DEPLOYMENT_KEY"); v != "" {
+		options.DeploymentKey = v
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_ENGINE"); v != "" {
+		options.EvaluationEngine = evaluationEngine(v)
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_FLAG_CONFIG_PATH"); v != "" {
+		options.FlagConfigPath = v
+	}
+	if v := os.Getenv("LOCAL_EVALUATION_CONFIG_START_IN_BACKGROUND"); v != "" {
+		background, err := strconv.ParseBool(v)
+		if err != nil {
+			return options, fmt.Errorf("LOCAL_EVALUATION_CONFIG_START_IN_BACKGROUND: %w", err)
+		}
+		options.StartInBackground = background
+	}
+	return options, nil
+}
+
+// Client evaluates feature flags locally. Each client polls its own flag
+// config, so several clients can be used in one process.
+type Client struct {
+	client *local.Client
+}
+
+// New creates a client and fetches its flag config, returning an error
+// instead of panicking if the first fetch fails. With
+// Options.StartInBackground the fetch happens in the background instead.
+func New(options Options) (*Client, error) {
+	config := &local.Config{
+		Debug:                          options.Debug,
+		ServerUrl:                      options.ServerUrl,
+		FlagConfigPollerInterval:       options.PollInterval,
+		FlagConfigPollerRequestTimeout: options.PollerRequestTimeout,
+		EvaluationEngine:               options.EvaluationEngine,
+		StartInBackground:              options.StartInBackground,
+		FlagConfigPath:                 options.FlagConfigPath,
+		HTTPClient:                     options.HTTPClient,
+		HTTP:                           options.HTTP,
+	}
+	client, err := local.New(options.DeploymentKey, config)
+	if err != nil {
+		return nil, err
+	}
+	err = client.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: client}, nil
+}
+
+// WaitForInitialization blocks until the client has loaded its flag config or
+// ctx is done.
+func (c *Client) WaitForInitialization(ctx context.Context) error {
+	if c == nil {
+		return ErrNotInitialized
+	}
+	return c.client.WaitForInitialization(ctx)
+}
+
+// Close stops polling and waits for in-flight fetches to finish or for ctx to
+// be done. Flags read from a closed client return their defaults.
+func (c *Client) Close(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Close(ctx)
+}
+
+func (c *Client) GetFeatureFlagString(flagName string, user UserContext) string {
+	data := c.fetch(flagName, user)
+	return data.Value
+}
+
+func (c *Client) GetFeatureFlagBool(flagName string, user UserContext) bool {
+	data := c.fetch(flagName, user)
+	if val, err := strconv.ParseBool(data.Value); err == nil {
+		return val
+	}
+	return false
+}
+
+func (c *Client) GetFeatureFlagPayload(flagName string, user UserContext) map[string]interface{} {
+	data := c.fetch(flagName, user)
+	mapData := make(map[string]interface{})
+	mapData["value"] = data.Value
+	mapData["payload"] = data.Payload
+	return mapData
+}
+
+func (c *Client) fetch(flagName string, user UserContext) variant {
+	if c == nil {
+		return variant{}
+	}
+	flagKeys := []string{flagName}
+	variants, err := c.client.Evaluate(experimentUser(user), flagKeys)
+	if err != nil {
+		return variant{}
+	}
+
+	v := variants[flagName]
+	return variant{Value: v.Value, Payload: v.Payload}
+}
+
+// evaluate returns the flag's variant, including default variants, or an
+// error if the client is nil or the flag is not found.
+func (c *Client) evaluate(flagName string, user UserContext) (experiment.Variant, error) {
+	if c == nil {
+		return experiment.Variant{}, ErrNotInitialized
+	}
+	options := &local.EvaluateOptions{IncludeDefaultVariants: true}
+	variants, err := c.client.EvaluateWithOptions(experimentUser(user), []string{flagName}, options)
+	if errors.Is(err, local.ErrFlagsNotLoaded) {
+		return experiment.Variant{}, ErrNotInitialized
+	}
+	if err != nil {
+		return experiment.Variant{}, err
+	}
+	v, ok := variants[flagName]
+	if !ok {
+		return experiment.Variant{}, fmt.Errorf("%w: %s", ErrFlagNotFound, flagName)
+	}
+	return v, nil
+}
@@ -1,26 +1,29 @@
 package localEvaluation
 
 import (
+	"context"
 	"fmt"
 	_ "github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation/lib/linuxArm64"
 	_ "github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation/lib/linuxX64"
 	_ "github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation/lib/macosArm64"
 	_ "github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation/lib/macosX64"
-	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
 	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment/local"
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
-	"time"
+	"strings"
 )
 
 var (
-	client                                    *local.Client
+	defaultClient                             *Client
 	LocalEvaluationConfigDebug                = true
 	LocalEvaluationConfigServerUrl            = "https://api.lambdatest.com"
 	LocalEvaluationConfigPollInterval         = 120
 	LocalEvaluationConfigPollerRequestTimeout = 10
 	LocalEvaluationDeploymentKey              = "server-jAqqJaX3l8PgNiJpcv9j20ywPzANQQFh"
+	LocalEvaluationConfigEngine               = ""
+	LocalEvaluationConfigFlagConfigPath       = ""
+	LocalEvaluationConfigStartInBackground    = false
 )
 
 type variant struct {
@@ -65,66 +68,60 @@ func init() {
 	if os.Getenv("LOCAL_EVALUATION_DEPLOYMENT_KEY") != "" {
 		LocalEvaluationDeploymentKey = os.Getenv("LOCAL_EVALUATION_DEPLOYMENT_KEY")
 	}
+	if os.Getenv("LOCAL_EVALUATION_CONFIG_ENGINE") != "" {
+		LocalEvaluationConfigEngine = os.Getenv("LOCAL_EVALUATION_CONFIG_ENGINE")
+	}
+	if os.Getenv("LOCAL_EVALUATION_CONFIG_FLAG_CONFIG_PATH") != "" {
+		LocalEvaluationConfigFlagConfigPath = os.Getenv("LOCAL_EVALUATION_CONFIG_FLAG_CONFIG_PATH")
+	}
+	if os.Getenv("LOCAL_EVALUATION_CONFIG_START_IN_BACKGROUND") != "" {
+		LocalEvaluationConfigStartInBackground, _ = strconv.ParseBool(os.Getenv("LOCAL_EVALUATION_CONFIG_START_IN_BACKGROUND"))
+	}
 }
 
+// Initialize creates the default client used by the package functions from
+// the LocalEvaluation* variables, which are read from the environment when the
+// package is loaded, and panics if it cannot be started. Use New and
+// SetDefault to handle the error instead.
 func Initialize() {
-	config := local.Config{
-		Debug:                          LocalEvaluationConfigDebug,
-		ServerUrl:                      LocalEvaluationConfigServerUrl,
-		FlagConfigPollerInterval:       time.Duration(LocalEvaluationConfigPollInterval) * time.Second,
-		FlagConfigPollerRequestTimeout: time.Duration(LocalEvaluationConfigPollerRequestTimeout) * time.Second,
-	}
-	client = local.Initialize(LocalEvaluationDeploymentKey, &config)
-	err := client.Start()
+	options := DefaultOptions()
+	c, err := New(options)
 	if err != nil {
-		err = fmt.Errorf("unable to create local evaluation client with given config %v with error %s", config, err.Error())
+		err = fmt.Errorf("unable to create local evaluation client for %s with error %s", options.ServerUrl, err.Error())
 		panic(err)
 	}
+	SetDefault(c)
 }
 
-func fetch(flagName string, user UserProperties) variant {
-	flagKeys := []string{flagName}
-	userProp := map[string]interface{}{
-		"org_id":            user.OrgId,
-		"org_name":          user.OrgName,
-		"username":          user.Username,
-		"email":             user.Email,
-		"plan":              user.Plan,
-		"subscription_type": user.SubscriptionType,
-		"hub_region":        user.HubRegion,
-		"infra_provider":    user.InfraProvider,
-		"template_id":       user.TemplateId,
-	}
+// SetDefault makes c the client used by the package functions.
+func SetDefault(c *Client) {
+	defaultClient = c
+}
 
-	expUser := experiment.User{
-		UserProperties: userProp,
-	}
+// WaitForInitialization blocks until the default client has loaded its flag
+// config or ctx is done.
+func WaitForInitialization(ctx context.Context) error {
+	return defaultClient.WaitForInitialization(ctx)
+}
 
-	variants, err := client.Evaluate(&expUser, flagKeys)
-	if err != nil {
-		return variant{}
+func evaluationEngine(name string) local.EvaluationEngine {
+	switch strings.ToLower(name) {
+	case "go":
+		return local.EvaluationEngineGo
+	case "interop":
+		return local.EvaluationEngineInterop
 	}
-
-	return variant(variants[flagName])
+	return local.EvaluationEngineDefault
 }
 
-func GetFeatureFlagString(flagName string, user UserProperties) string {
-	data := fetch(flagName, user)
-	return data.Value
+func GetFeatureFlagString(flagName string, user UserContext) string {
+	return defaultClient.GetFeatureFlagString(flagName, user)
 }
 
-func GetFeatureFlagBool(flagName string, user UserProperties) bool {
-	data := fetch(flagName, user)
-	if val, err := strconv.ParseBool(data.Value); err == nil {
-		return val
-	}
-	return false
+func GetFeatureFlagBool(flagName string, user UserContext) bool {
+	return defaultClient.GetFeatureFlagBool(flagName, user)
 }
 
-func GetFeatureFlagPayload(flagName string, user UserProperties) map[string]interface{} {
-	data := fetch(flagName, user)
-	mapData := make(map[string]interface{})
-	mapData["value"] = data.Value
-	mapData["payload"] = data.Payload
-	return mapData
+func GetFeatureFlagPayload(flagName string, user UserContext) map[string]interface{} {
+	return defaultClient.GetFeatureFlagPayload(flagName, user)
 }
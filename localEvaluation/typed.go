@@ -0,0 +1,118 @@
+package localEvaluation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment/local"
+)
+
+var (
+	// ErrNotInitialized is returned when a flag is read before Initialize or
+	// before the client has loaded its first flag config.
+	ErrNotInitialized = errors.New("local evaluation client is not initialized")
+	// ErrFlagNotFound is returned when the flag is not in the loaded config.
+	ErrFlagNotFound = local.ErrFlagNotFound
+	// ErrFlagConfigStale is returned when the flag config is stale and is not
+	// being served.
+	ErrFlagConfigStale = local.ErrFlagConfigStale
+)
+
+// ParseError is returned when a flag's value or payload cannot be converted
+// to the requested type.
+type ParseError struct {
+	FlagName string
+	Type     string
+	Value    string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unable to parse feature flag %s value %q as %s: %v", e.FlagName, e.Value, e.Type, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FlagValue lists the types GetFeatureFlag can parse a variant value into.
+type FlagValue interface {
+	bool | int | int64 | float64 | string | time.Duration
+}
+
+// GetFeatureFlag returns the flag's variant value parsed as T. defaultValue is
+// returned when the flag serves its default variant, and together with an
+// error when the client is not initialized, its flags are stale and not
+// served, the flag is not found, or the value cannot be parsed. Bool flags
+// accept "on" and "off" as well as the values understood by
+// strconv.ParseBool.
+func GetFeatureFlag[T FlagValue](flagName string, user UserContext, defaultValue T) (T, error) {
+	return GetFeatureFlagFrom(defaultClient, flagName, user, defaultValue)
+}
+
+// GetFeatureFlagFrom is GetFeatureFlag evaluated by c.
+func GetFeatureFlagFrom[T FlagValue](c *Client, flagName string, user UserContext, defaultValue T) (T, error) {
+	v, err := c.evaluate(flagName, user)
+	if err != nil || v.IsDefaultVariant {
+		return defaultValue, err
+	}
+	var value T
+	var parseErr error
+	switch p := any(&value).(type) {
+	case *bool:
+		*p, parseErr = parseBool(v.Value)
+	case *int:
+		*p, parseErr = strconv.Atoi(v.Value)
+	case *int64:
+		*p, parseErr = strconv.ParseInt(v.Value, 10, 64)
+	case *float64:
+		*p, parseErr = strconv.ParseFloat(v.Value, 64)
+	case *string:
+		*p = v.Value
+	case *time.Duration:
+		*p, parseErr = time.ParseDuration(v.Value)
+	}
+	if parseErr != nil {
+		return defaultValue, &ParseError{FlagName: flagName, Type: fmt.Sprintf("%T", value), Value: v.Value, Err: parseErr}
+	}
+	return value, nil
+}
+
+// GetFeatureFlagJSON decodes the flag's variant payload into T, which is
+// typically a struct. defaultValue is returned in the same cases as for
+// GetFeatureFlag, and also when the variant has no payload.
+func GetFeatureFlagJSON[T any](flagName string, user UserContext, defaultValue T) (T, error) {
+	return GetFeatureFlagJSONFrom(defaultClient, flagName, user, defaultValue)
+}
+
+// GetFeatureFlagJSONFrom is GetFeatureFlagJSON evaluated by c.
+func GetFeatureFlagJSONFrom[T any](c *Client, flagName string, user UserContext, defaultValue T) (T, error) {
+	v, err := c.evaluate(flagName, user)
+	if err != nil || v.IsDefaultVariant || v.Payload == nil {
+		return defaultValue, err
+	}
+	data, err := json.Marshal(v.Payload)
+	if err != nil {
+		return defaultValue, &ParseError{FlagName: flagName, Type: fmt.Sprintf("%T", defaultValue), Value: fmt.Sprintf("%v", v.Payload), Err: err}
+	}
+	var value T
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return defaultValue, &ParseError{FlagName: flagName, Type: fmt.Sprintf("%T", defaultValue), Value: string(data), Err: err}
+	}
+	return value, nil
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
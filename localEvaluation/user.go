@@ -0,0 +1,161 @@
+package localEvaluation
+
+import (
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// UserContext is the user a flag is evaluated for. It is implemented by
+// UserProperties and by the builder returned from NewUser.
+type UserContext interface {
+	ExperimentUser() *experiment.User
+}
+
+func experimentUser(user UserContext) *experiment.User {
+	if user == nil {
+		return &experiment.User{}
+	}
+	return user.ExperimentUser()
+}
+
+// ExperimentUser adapts the LambdaTest properties to an experiment.User. It
+// sends the same properties as earlier releases, including empty ones, and
+// leaves out UserStatus.
+func (p UserProperties) ExperimentUser() *experiment.User {
+	return &experiment.User{
+		UserProperties: map[string]interface{}{
+			"org_id":            p.OrgId,
+			"org_name":          p.OrgName,
+			"username":          p.Username,
+			"email":             p.Email,
+			"plan":              p.Plan,
+			"subscription_type": p.SubscriptionType,
+			"hub_region":        p.HubRegion,
+			"infra_provider":    p.InfraProvider,
+			"template_id":       p.TemplateId,
+		},
+	}
+}
+
+// UserBuilder builds a UserContext from arbitrary user properties and the
+// top-level experiment.User fields. Empty values are left out.
+type UserBuilder struct {
+	user experiment.User
+}
+
+func NewUser() *UserBuilder {
+	return &UserBuilder{}
+}
+
+// NewUserFrom starts a builder from a copy of user.
+func NewUserFrom(user *experiment.User) *UserBuilder {
+	b := &UserBuilder{}
+	if user != nil {
+		b.user = *user
+		b.user.UserProperties = nil
+		b.Properties(user.UserProperties)
+	}
+	return b
+}
+
+func (b *UserBuilder) UserId(userId string) *UserBuilder {
+	b.user.UserId = userId
+	return b
+}
+
+func (b *UserBuilder) DeviceId(deviceId string) *UserBuilder {
+	b.user.DeviceId = deviceId
+	return b
+}
+
+func (b *UserBuilder) Country(country string) *UserBuilder {
+	b.user.Country = country
+	return b
+}
+
+func (b *UserBuilder) Region(region string) *UserBuilder {
+	b.user.Region = region
+	return b
+}
+
+func (b *UserBuilder) Dma(dma string) *UserBuilder {
+	b.user.Dma = dma
+	return b
+}
+
+func (b *UserBuilder) City(city string) *UserBuilder {
+	b.user.City = city
+	return b
+}
+
+func (b *UserBuilder) Language(language string) *UserBuilder {
+	b.user.Language = language
+	return b
+}
+
+func (b *UserBuilder) Platform(platform string) *UserBuilder {
+	b.user.Platform = platform
+	return b
+}
+
+func (b *UserBuilder) Version(version string) *UserBuilder {
+	b.user.Version = version
+	return b
+}
+
+func (b *UserBuilder) Os(os string) *UserBuilder {
+	b.user.Os = os
+	return b
+}
+
+func (b *UserBuilder) DeviceManufacturer(deviceManufacturer string) *UserBuilder {
+	b.user.DeviceManufacturer = deviceManufacturer
+	return b
+}
+
+func (b *UserBuilder) DeviceBrand(deviceBrand string) *UserBuilder {
+	b.user.DeviceBrand = deviceBrand
+	return b
+}
+
+func (b *UserBuilder) DeviceModel(deviceModel string) *UserBuilder {
+	b.user.DeviceModel = deviceModel
+	return b
+}
+
+func (b *UserBuilder) Carrier(carrier string) *UserBuilder {
+	b.user.Carrier = carrier
+	return b
+}
+
+// Property sets a user property. Nil values and empty strings remove it.
+func (b *UserBuilder) Property(name string, value interface{}) *UserBuilder {
+	if value == nil || value == "" {
+		delete(b.user.UserProperties, name)
+		return b
+	}
+	if b.user.UserProperties == nil {
+		b.user.UserProperties = make(map[string]interface{})
+	}
+	b.user.UserProperties[name] = value
+	return b
+}
+
+func (b *UserBuilder) Properties(properties map[string]interface{}) *UserBuilder {
+	for name, value := range properties {
+		b.Property(name, value)
+	}
+	return b
+}
+
+// ExperimentUser returns a copy of the built user, so the builder can keep
+// being modified and reused.
+func (b *UserBuilder) ExperimentUser() *experiment.User {
+	user := b.user
+	if b.user.UserProperties != nil {
+		user.UserProperties = make(map[string]interface{}, len(b.user.UserProperties))
+		for name, value := range b.user.UserProperties {
+			user.UserProperties[name] = value
+		}
+	}
+	return &user
+}
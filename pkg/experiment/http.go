@@ -0,0 +1,102 @@
+package experiment
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HTTPConfig configures the HTTP client that local and remote evaluation
+// clients use to reach the flag server.
+type HTTPConfig struct {
+	// Transport sends the requests, for example through a tracing round
+	// tripper. It cannot be combined with the other options, which configure
+	// the default transport.
+	Transport http.RoundTripper
+	// ProxyUrl is the proxy requests are sent through. Empty uses the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	ProxyUrl string
+	// CAFile is a PEM bundle of certificate authorities trusted in addition
+	// to the system ones.
+	CAFile string
+	// CertFile and KeyFile are a PEM certificate and key presented to the
+	// server for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeout tune connection
+	// reuse. Zero values keep the net/http defaults.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// NewHTTPClient returns an http.Client configured by config, which may be
+// nil. It returns an error when Transport is set along with other options.
+func NewHTTPClient(config *HTTPConfig) (*http.Client, error) {
+	if config == nil {
+		return &http.Client{}, nil
+	}
+	if config.Transport != nil {
+		if config.ProxyUrl != "" || config.CAFile != "" || config.CertFile != "" || config.KeyFile != "" ||
+			config.MaxIdleConns != 0 || config.MaxIdleConnsPerHost != 0 || config.IdleConnTimeout != 0 {
+			return nil, errors.New("http transport cannot be combined with proxy, tls or connection options")
+		}
+		return &http.Client{Transport: config.Transport}, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.ProxyUrl != "" {
+		proxy, err := url.Parse(config.ProxyUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if config.CAFile != "" || config.CertFile != "" || config.KeyFile != "" {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if config.MaxIdleConns != 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+func (c *HTTPConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
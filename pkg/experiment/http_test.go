@@ -0,0 +1,147 @@
+package experiment
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate issues a certificate for name, signed by parent or self
+// signed when parent is nil, and writes it and its key as PEM to dir.
+func testCertificate(t *testing.T, dir, name string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, any(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, name+".pem"), "CERTIFICATE", der)
+	writePEM(t, filepath.Join(dir, name+"-key.pem"), "EC PRIVATE KEY", keyDer)
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewHTTPClientMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCertificate(t, dir, "ca", nil)
+	serverCert := testCertificate(t, dir, "server", &ca)
+	testCertificate(t, dir, "client", &ca)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewHTTPClient(&HTTPConfig{
+		CAFile:   filepath.Join(dir, "ca.pem"),
+		CertFile: filepath.Join(dir, "client.pem"),
+		KeyFile:  filepath.Join(dir, "client-key.pem"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(body); got != "client" {
+		t.Errorf("server saw client certificate %q, want %q", got, "client")
+	}
+
+	// Without the CA bundle the server certificate is not trusted.
+	client, err = NewHTTPClient(&HTTPConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("Get() succeeded without trusting the test CA")
+	}
+}
+
+func TestNewHTTPClientErrors(t *testing.T) {
+	dir := t.TempDir()
+	testCertificate(t, dir, "ca", nil)
+	tests := []struct {
+		name   string
+		config *HTTPConfig
+	}{
+		{"transport with proxy", &HTTPConfig{Transport: http.DefaultTransport, ProxyUrl: "http://proxy"}},
+		{"transport with ca", &HTTPConfig{Transport: http.DefaultTransport, CAFile: filepath.Join(dir, "ca.pem")}},
+		{"transport with key pair", &HTTPConfig{Transport: http.DefaultTransport, CertFile: "cert.pem", KeyFile: "key.pem"}},
+		{"transport with pool limits", &HTTPConfig{Transport: http.DefaultTransport, MaxIdleConns: 1}},
+		{"invalid proxy", &HTTPConfig{ProxyUrl: "://proxy"}},
+		{"missing ca", &HTTPConfig{CAFile: filepath.Join(dir, "missing.pem")}},
+		{"ca without certificates", &HTTPConfig{CAFile: filepath.Join(dir, "ca-key.pem")}},
+		{"cert without key", &HTTPConfig{CertFile: filepath.Join(dir, "ca.pem")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHTTPClient(tt.config); err == nil {
+				t.Error("NewHTTPClient() succeeded")
+			}
+		})
+	}
+	if _, err := NewHTTPClient(&HTTPConfig{Transport: http.DefaultTransport}); err != nil {
+		t.Errorf("NewHTTPClient() with only a transport = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package local
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// EvaluateBatch evaluates flagKeys for every user against one flag config.
+// The flags are selected once and users are evaluated concurrently by at most
+// Config.BatchEvaluationConcurrency workers. The returned slices are indexed
+// like users, and an error only affects the user at its index.
+func (c *Client) EvaluateBatch(users []*experiment.User, flagKeys []string) ([]map[string]experiment.Variant, []error) {
+	results := make([]map[string]experiment.Variant, len(users))
+	errs := make([]error, len(users))
+	flags, _, err := c.evaluationFlags()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return results, errs
+	}
+	if flags.empty() {
+		c.log.Debug("evaluate batch: no flags")
+		for i := range results {
+			results[i] = make(map[string]experiment.Variant)
+		}
+		return results, errs
+	}
+	selection := flags.selection(c.engine, flagKeys)
+	workers := c.config.BatchEvaluationConcurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(users) {
+		workers = len(users)
+	}
+	c.log.Debug("evaluate batch: %v users, %v workers", len(users), workers)
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result, err := c.evaluate(selection, users[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i] = toVariants(result, false)
+			}
+		}()
+	}
+	for i := range users {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, errs
+}
@@ -0,0 +1,113 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachedFlags is the on-disk form of a flag set, see
+// Config.FlagConfigCachePath.
+type cachedFlags struct {
+	Version      uint64          `json:"version"`
+	FetchedAt    time.Time       `json:"fetchedAt"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"lastModified,omitempty"`
+	Flags        json.RawMessage `json:"flags"`
+}
+
+// loadCachedFlags stores the flag set cached on disk, if any, and reports
+// whether it did. The set keeps its version and fetch time, so a cold start
+// from an old cache is still subject to the stale policy.
+func (c *Client) loadCachedFlags() bool {
+	path := c.config.FlagConfigCachePath
+	if path == "" {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.log.Error("unable to read flag config cache: %v", err)
+		}
+		return false
+	}
+	var cached cachedFlags
+	err = json.Unmarshal(data, &cached)
+	if err != nil {
+		c.log.Error("unable to decode flag config cache %s: %v", path, err)
+		return false
+	}
+	flags, err := parseFlagSet(cached.Flags)
+	if err != nil {
+		c.log.Error("unable to parse flag config cache %s: %v", path, err)
+		return false
+	}
+	flags.version = cached.Version
+	flags.etag = cached.ETag
+	flags.lastModified = cached.LastModified
+	err = c.storeFlags(flags)
+	if err != nil {
+		c.log.Error("flag config cache %s rejected: %v", path, err)
+		return false
+	}
+	c.status.restore(cached.FetchedAt)
+	c.log.Debug("loaded flag config version %d fetched at %v from cache", flags.version, cached.FetchedAt)
+	c.deliverFlagChanges()
+	return true
+}
+
+// saveFlags writes flags to the cache file, if one is configured, with the
+// current time as when they were fetched. It is called after every fetch that
+// accepts or confirms flags. Flags that are no longer the current flag set are
+// not written, so a slower update never overwrites the cache with an older
+// config.
+func (c *Client) saveFlags(flags *flagSet) {
+	path := c.config.FlagConfigCachePath
+	if path == "" {
+		return
+	}
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	if c.flags.Load() != flags {
+		c.log.Debug("flag config version %d replaced before it was cached", flags.version)
+		return
+	}
+	err := writeFlagCache(path, flags, time.Now())
+	if err != nil {
+		c.log.Error("unable to write flag config cache: %v", err)
+	}
+}
+
+// writeFlagCache replaces the file at path atomically, so a crash never
+// leaves a partially written cache behind.
+func writeFlagCache(path string, flags *flagSet, fetchedAt time.Time) error {
+	data, err := json.Marshal(&cachedFlags{
+		Version:      flags.version,
+		FetchedAt:    fetchedAt,
+		ETag:         flags.etag,
+		LastModified: flags.lastModified,
+		Flags:        json.RawMessage(flags.raw),
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
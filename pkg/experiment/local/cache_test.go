@@ -0,0 +1,87 @@
+package local
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFlagCache(t *testing.T, path string) cachedFlags {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cached cachedFlags
+	err = json.Unmarshal(data, &cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cached
+}
+
+func TestFlagCacheRefreshedOnNotModified(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(testFlags))
+	})
+	path := filepath.Join(t.TempDir(), "flags.json")
+	client := newTestClient(t, &Config{
+		ServerUrl:                server.URL,
+		FlagConfigCachePath:      path,
+		FlagConfigPollerInterval: 10 * time.Millisecond,
+	})
+	err := client.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := readFlagCache(t, path)
+	waitFor(t, "a poll answered Not Modified", func() bool {
+		return client.PollerStats().NotModified > 0
+	})
+	waitFor(t, "the cache to be refreshed", func() bool {
+		return readFlagCache(t, path).FetchedAt.After(first.FetchedAt)
+	})
+	if cached := readFlagCache(t, path); cached.ETag != `"v1"` || cached.Version != first.Version {
+		t.Errorf("cache has etag %s version %d, want %s version %d", cached.ETag, cached.Version, `"v1"`, first.Version)
+	}
+}
+
+func TestFlagCacheColdStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	flags, err := parseFlagSet([]byte(testFlags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags.version = 7
+	fetchedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	err = writeFlagCache(path, flags, fetchedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	})
+	client := newTestClient(t, &Config{
+		ServerUrl:                server.URL,
+		FlagConfigCachePath:      path,
+		FlagConfigPollerInterval: time.Hour,
+	})
+	err = client.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := client.FlagConfigVersion(); version != 7 {
+		t.Errorf("FlagConfigVersion() = %d, want 7", version)
+	}
+	if last := client.LastSuccessfulUpdate(); !last.Equal(fetchedAt) {
+		t.Errorf("LastSuccessfulUpdate() = %v, want %v", last, fetchedAt)
+	}
+}
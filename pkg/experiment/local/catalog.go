@@ -0,0 +1,101 @@
+package local
+
+import (
+	"sort"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
+)
+
+// FlagInfo describes a loaded flag.
+type FlagInfo struct {
+	Key         string
+	Description string
+	Enabled     bool
+	// Variants lists the flag's variants in config order. Payloads are
+	// shared with the loaded config and must not be modified.
+	Variants []FlagVariant
+	// DefaultVariant is the key of the variant served when no segment
+	// assigns one.
+	DefaultVariant string
+	// Prerequisites lists the keys of the flags this flag depends on.
+	Prerequisites []string
+}
+
+// FlagCatalog lists the loaded flags.
+type FlagCatalog struct {
+	// Version is the version of the flag config the flags were read from,
+	// or 0 if no config is loaded.
+	Version uint64
+	// Flags is sorted by key.
+	Flags []FlagInfo
+}
+
+// Catalog describes every loaded flag without fetching the flag config.
+func (c *Client) Catalog() FlagCatalog {
+	flags := c.flags.Load()
+	if flags == nil {
+		return FlagCatalog{}
+	}
+	catalog := FlagCatalog{
+		Version: flags.version,
+		Flags:   make([]FlagInfo, 0, len(flags.flags)),
+	}
+	for _, flag := range flags.flags {
+		catalog.Flags = append(catalog.Flags, flagInfo(flag))
+	}
+	sort.Slice(catalog.Flags, func(i, j int) bool {
+		return catalog.Flags[i].Key < catalog.Flags[j].Key
+	})
+	return catalog
+}
+
+// FlagKeys returns the keys of the loaded flags in sorted order.
+func (c *Client) FlagKeys() []string {
+	flags := c.flags.Load()
+	if flags == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(flags.flags))
+	for _, flag := range flags.flags {
+		keys = append(keys, flag.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// FlagInfo describes the loaded flag with key, and reports whether it is
+// loaded.
+func (c *Client) FlagInfo(key string) (FlagInfo, bool) {
+	flag := c.flags.Load().lookup(key)
+	if flag == nil {
+		return FlagInfo{}, false
+	}
+	return flagInfo(flag), true
+}
+
+// FlagConfigVersion returns the version of the loaded flag config, or 0 if
+// none is loaded.
+func (c *Client) FlagConfigVersion() uint64 {
+	flags := c.flags.Load()
+	if flags == nil {
+		return 0
+	}
+	return flags.version
+}
+
+func flagInfo(flag *evaluation.Flag) FlagInfo {
+	info := FlagInfo{
+		Key:            flag.Key,
+		Description:    flag.Description,
+		Enabled:        flag.Enabled,
+		Variants:       make([]FlagVariant, 0, len(flag.Variants)),
+		DefaultVariant: flag.DefaultVariant().Key,
+		Prerequisites:  flag.Parents(),
+	}
+	for _, variant := range flag.Variants {
+		if variant != nil {
+			info.Variants = append(info.Variants, FlagVariant{Key: variant.Key, Payload: variant.Payload})
+		}
+	}
+	return info
+}
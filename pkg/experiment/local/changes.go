@@ -0,0 +1,154 @@
+package local
+
+import (
+	"sort"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
+)
+
+type FlagChangeType string
+
+const (
+	FlagAdded    FlagChangeType = "added"
+	FlagRemoved  FlagChangeType = "removed"
+	FlagModified FlagChangeType = "modified"
+)
+
+// FlagChange describes how one flag differs between two flag configs.
+type FlagChange struct {
+	FlagKey string
+	Type    FlagChangeType
+	// Fields lists the names of the changed config fields, as they appear
+	// in the sdk/v1/flags JSON, for modified flags.
+	Fields []string
+}
+
+// FlagChangeEvent describes the changes between two consecutive flag configs.
+type FlagChangeEvent struct {
+	OldVersion uint64
+	NewVersion uint64
+	Changes    []FlagChange
+}
+
+// AddFlagChangeListener registers listener to be called after every flag
+// config update that changes at least one flag. Listeners are called one at a
+// time, in the order the updates were stored, from a goroutine that applied an
+// update. A listener that closes the client does not wait for polling to
+// stop, see Close. The returned function removes the listener.
+func (c *Client) AddFlagChangeListener(listener func(event FlagChangeEvent)) func() {
+	c.listenersMutex.Lock()
+	defer c.listenersMutex.Unlock()
+	if c.listeners == nil {
+		c.listeners = make(map[int]func(FlagChangeEvent))
+	}
+	id := c.nextListener
+	c.nextListener++
+	c.listeners[id] = listener
+	return func() {
+		c.listenersMutex.Lock()
+		defer c.listenersMutex.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+// SubscribeFlag registers listener to be called whenever flagKey is added,
+// removed or modified. The returned function removes the subscription.
+func (c *Client) SubscribeFlag(flagKey string, listener func(change FlagChange)) func() {
+	return c.AddFlagChangeListener(func(event FlagChangeEvent) {
+		for _, change := range event.Changes {
+			if change.FlagKey == flagKey {
+				listener(change)
+			}
+		}
+	})
+}
+
+// queueFlagChanges queues the diff between previous and current for
+// deliverFlagChanges. It is called with flagsMutex held, so events are queued
+// in the order the flag sets were stored.
+func (c *Client) queueFlagChanges(previous, current *flagSet) {
+	changes := diffFlagSets(previous, current)
+	if len(changes) == 0 {
+		return
+	}
+	event := FlagChangeEvent{NewVersion: current.version, Changes: changes}
+	if previous != nil {
+		event.OldVersion = previous.version
+	}
+	c.changesMutex.Lock()
+	defer c.changesMutex.Unlock()
+	c.changes = append(c.changes, event)
+}
+
+// deliverFlagChanges delivers the queued events in order. When another
+// goroutine, or a listener further up this one's stack, is already delivering,
+// it returns at once and leaves the events to that delivery.
+func (c *Client) deliverFlagChanges() {
+	c.changesMutex.Lock()
+	if c.delivering {
+		c.changesMutex.Unlock()
+		return
+	}
+	c.delivering = true
+	for len(c.changes) != 0 {
+		event := c.changes[0]
+		c.changes = c.changes[1:]
+		c.changesMutex.Unlock()
+		c.notifyFlagChanges(event)
+		c.changesMutex.Lock()
+	}
+	c.delivering = false
+	c.changesMutex.Unlock()
+}
+
+// notifyFlagChanges calls the registered listeners with event.
+func (c *Client) notifyFlagChanges(event FlagChangeEvent) {
+	c.log.Debug("flag changes: %v", event)
+	c.listenersMutex.Lock()
+	ids := make([]int, 0, len(c.listeners))
+	for id := range c.listeners {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	listeners := make([]func(FlagChangeEvent), 0, len(ids))
+	for _, id := range ids {
+		listeners = append(listeners, c.listeners[id])
+	}
+	c.listenersMutex.Unlock()
+	for _, listener := range listeners {
+		c.callListener(listener, event)
+	}
+}
+
+func (c *Client) callListener(listener func(FlagChangeEvent), event FlagChangeEvent) {
+	c.listening.Add(1)
+	defer c.listening.Add(-1)
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("flag change listener panicked: %v", r)
+		}
+	}()
+	listener(event)
+}
+
+// diffFlagSets lists added and modified flags in the order of current,
+// followed by removed flags in the order of previous.
+func diffFlagSets(previous, current *flagSet) []FlagChange {
+	var changes []FlagChange
+	for _, flag := range current.flags {
+		old := previous.lookup(flag.Key)
+		if old == nil {
+			changes = append(changes, FlagChange{FlagKey: flag.Key, Type: FlagAdded})
+		} else if fields := evaluation.ChangedFields(old, flag); len(fields) != 0 {
+			changes = append(changes, FlagChange{FlagKey: flag.Key, Type: FlagModified, Fields: fields})
+		}
+	}
+	if previous != nil {
+		for _, flag := range previous.flags {
+			if current.lookup(flag.Key) == nil {
+				changes = append(changes, FlagChange{FlagKey: flag.Key, Type: FlagRemoved})
+			}
+		}
+	}
+	return changes
+}
@@ -3,11 +3,15 @@ package local
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
 
@@ -24,63 +28,319 @@ type Client struct {
 	apiKey string
 	config *Config
 	client *http.Client
+	source FlagConfigSource
 	poller *poller
-	flags  *string
+	engine EvaluationEngine
+	// flags holds the current flag set. It is replaced as a whole, never
+	// modified, so readers see one consistent config without locking.
+	flags      atomic.Pointer[flagSet]
+	flagsMutex sync.Mutex
+	// listeners are notified of flag changes, see AddFlagChangeListener.
+	listeners      map[int]func(FlagChangeEvent)
+	nextListener   int
+	listenersMutex sync.Mutex
+	// changes queues flag change events in version order until they are
+	// delivered; delivering is set while a goroutine delivers them.
+	changes      []FlagChangeEvent
+	delivering   bool
+	changesMutex sync.Mutex
+	// listening counts the flag change listeners that are running.
+	listening atomic.Int32
+	// cacheMutex serialises writes to the flag config cache.
+	cacheMutex sync.Mutex
+	stats      pollerStats
+	status     updateStatus
+	// streaming is set while the flag stream is connected, which pauses
+	// polling.
+	streaming atomic.Bool
+	// ctx is cancelled by Close to stop polling and in-flight fetches.
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed atomic.Bool
+	// rejected holds the validators of the last fetched config that failed
+	// validation, and rejectedErr the reason. Only the goroutine updating
+	// flags uses them.
+	rejected    *FlagConfig
+	rejectedErr error
+	// initialized is closed once the first flag config is stored.
+	initialized chan struct{}
+	initOnce    sync.Once
 }
 
 func Initialize(apiKey string, config *Config) *Client {
 	initMutex.Lock()
+	defer initMutex.Unlock()
 	client := clients[apiKey]
 	if client == nil {
-		if apiKey == "" {
+		if apiKey == "" && needsApiKey(config) {
 			panic("api key must be set")
 		}
-		config = fillConfigDefaults(config)
-		client = &Client{
-			log:    logger.New(config.Debug),
-			apiKey: apiKey,
-			config: config,
-			client: &http.Client{},
-			poller: newPoller(),
+		var err error
+		client, err = newClient(apiKey, config)
+		if err != nil {
+			panic(err)
+		}
+		if client.engine == EvaluationEngineInterop && !evaluation.InteropAvailable {
+			client.log.Error("evaluation interop library is not available in this build without cgo, set EvaluationEngine to EvaluationEngineGo")
 		}
-		client.log.Debug("config: %v", *config)
+		clients[apiKey] = client
 	}
-	initMutex.Unlock()
 	return client
 }
 
-func (c *Client) Start() error {
-	result, err := c.doFlags()
+// New creates a client that is independent of the clients shared through
+// Initialize, so several clients may use the same api key.
+func New(apiKey string, config *Config) (*Client, error) {
+	if apiKey == "" && needsApiKey(config) {
+		return nil, errors.New("api key must be set")
+	}
+	client, err := newClient(apiKey, config)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	c.flags = result
-	c.poller.Poll(c.config.FlagConfigPollerInterval, func() {
-		result, err := c.doFlags()
+	if client.engine == EvaluationEngineInterop && !evaluation.InteropAvailable {
+		return nil, errors.New("evaluation interop library is not available in this build without cgo, set EvaluationEngine to EvaluationEngineGo")
+	}
+	return client, nil
+}
+
+func newClient(apiKey string, config *Config) (*Client, error) {
+	config = fillConfigDefaults(config)
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		var err error
+		httpClient, err = experiment.NewHTTPClient(config.HTTP)
 		if err != nil {
-			return
+			return nil, err
 		}
-		c.flags = result
-	})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &Client{
+		log:         logger.New(config.Debug),
+		apiKey:      apiKey,
+		config:      config,
+		client:      httpClient,
+		poller:      newPoller(config.FlagConfigPollerJitter, config.FlagConfigPollerMaxBackoff),
+		engine:      config.EvaluationEngine.resolve(),
+		ctx:         ctx,
+		cancel:      cancel,
+		initialized: make(chan struct{}),
+	}
+	client.source = config.FlagConfigSource
+	if client.source == nil {
+		client.source = client.defaultSource()
+	}
+	client.log.Debug("config: %v", *config)
+	return client, nil
+}
 
+// defaultSource returns the source for Config.FlagConfigPath if it is set,
+// and for the flag server otherwise.
+func (c *Client) defaultSource() FlagConfigSource {
+	if c.config.FlagConfigPath != "" {
+		return &FileFlagConfigSource{Path: c.config.FlagConfigPath}
+	}
+	return &HTTPFlagConfigSource{
+		ServerUrl:              c.config.ServerUrl,
+		StreamServerUrl:        c.config.StreamServerUrl,
+		ApiKey:                 c.apiKey,
+		Client:                 c.client,
+		RequestTimeout:         c.config.FlagConfigPollerRequestTimeout,
+		StreamKeepAliveTimeout: c.config.StreamKeepAliveTimeout,
+	}
+}
+
+// needsApiKey reports whether config fetches flags from the flag server.
+func needsApiKey(config *Config) bool {
+	return config == nil || (config.FlagConfigSource == nil && config.FlagConfigPath == "")
+}
+
+func (c *Client) Start() error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	cached := c.loadCachedFlags()
+	if c.config.StartInBackground {
+		c.poller.Go(func() {
+			_ = c.initialUpdate(c.ctx, c.config.InitialFetchRetries)
+			c.startUpdates()
+		})
+		return nil
+	}
+	err := c.initialUpdate(c.ctx, 0)
+	if err != nil && !cached {
+		return err
+	}
+	c.startUpdates()
 	return nil
 }
 
+// WaitForInitialization blocks until the client has loaded a flag config or
+// ctx is done, in which case the error wraps ctx.Err() and describes the last
+// failed update.
+func (c *Client) WaitForInitialization(ctx context.Context) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	select {
+	case <-c.initialized:
+		return nil
+	case <-c.ctx.Done():
+		return ErrClientClosed
+	case <-ctx.Done():
+		if err := c.LastError(); err != nil {
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+		return ctx.Err()
+	}
+}
+
+// initialUpdate loads the first flag config, retrying failed fetches up to
+// retries times. A rejected config is not retried, since fetching it again
+// would only reject it again.
+func (c *Client) initialUpdate(ctx context.Context, retries int) error {
+	backoff := c.config.InitialFetchRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.updateFlags(ctx)
+		if err == nil || attempt >= retries || ctx.Err() != nil ||
+			errors.Is(err, ErrFlagConfigRejected) {
+			return err
+		}
+		c.log.Error("initial flag config fetch failed, retrying in %v: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// startUpdates starts the subscription to the flag config source, if enabled
+// and supported, and the poller, which watches the flag files instead for a
+// file source.
+func (c *Client) startUpdates() {
+	if _, ok := c.source.(*FileFlagConfigSource); ok {
+		c.poller.Poll(c.ctx, c.config.FlagConfigWatchInterval, func(ctx context.Context) error {
+			err := c.updateFlags(ctx)
+			if err != nil && !errors.Is(err, ErrFlagConfigRejected) {
+				c.log.Error("unable to read flag config files: %v", err)
+			}
+			// Files are cheap to check, so keep watching at the same
+			// interval to pick up a fix as soon as it is saved.
+			return nil
+		})
+		return
+	}
+	if subscriber, ok := c.source.(FlagConfigSubscriber); ok && c.config.StreamUpdates {
+		c.poller.Go(func() {
+			c.stream(c.ctx, subscriber)
+		})
+	}
+	c.poller.Poll(c.ctx, c.config.FlagConfigPollerInterval, func(ctx context.Context) error {
+		if c.streaming.Load() {
+			return nil
+		}
+		err := c.updateFlags(ctx)
+		if err != nil && ctx.Err() == nil && !errors.Is(err, ErrFlagConfigRejected) {
+			c.log.Error("flag config poll failed (%d consecutive failures): %v", c.stats.consecutiveFailures.Load(), err)
+		}
+		return err
+	})
+}
+
+// Close stops polling, cancels in-flight flag fetches and waits for them to
+// return or for ctx to be done. A client returned by Initialize is removed
+// from the registry, so the next Initialize with its api key creates a new
+// client. Evaluating with a closed client returns ErrClientClosed.
+//
+// Listeners may be called from the goroutines Close waits for, so while a
+// flag change listener is running, for example when the listener itself
+// calls Close, Close cancels polling and returns without waiting.
+func (c *Client) Close(ctx context.Context) error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	initMutex.Lock()
+	if clients[c.apiKey] == c {
+		delete(clients, c.apiKey)
+	}
+	initMutex.Unlock()
+	c.cancel()
+	if c.listening.Load() > 0 {
+		return nil
+	}
+	return c.poller.Wait(ctx)
+}
+
 func (c *Client) Evaluate(user *experiment.User, flagKeys []string) (map[string]experiment.Variant, error) {
-	variants := make(map[string]experiment.Variant)
-	if len(*c.flags) == 0 {
+	return c.EvaluateWithOptions(user, flagKeys, nil)
+}
+
+// EvaluateContext is Evaluate for a caller whose ctx may already be done, in
+// which case ctx.Err() is returned without evaluating. Evaluation itself
+// runs on the loaded flags and never waits on the network.
+func (c *Client) EvaluateContext(ctx context.Context, user *experiment.User, flagKeys []string) (map[string]experiment.Variant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.EvaluateWithOptions(user, flagKeys, nil)
+}
+
+// EvaluateWithOptions is Evaluate with per-call options. When
+// options.IncludeDefaultVariants is set, flags that evaluate to their default
+// variant are returned with IsDefaultVariant set, and requested flags absent
+// from the loaded config are reported by a *FlagsNotFoundError returned
+// alongside the variants of the flags that were found. In that mode, an empty
+// result is returned with ErrFlagsNotLoaded before flags are loaded, and with
+// ErrFlagConfigStale while stale flags are withheld by StalePolicyDefaults.
+func (c *Client) EvaluateWithOptions(user *experiment.User, flagKeys []string, options *EvaluateOptions) (map[string]experiment.Variant, error) {
+	flags, stale, err := c.evaluationFlags()
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &EvaluateOptions{}
+	}
+	var notFoundErr error
+	if options.IncludeDefaultVariants {
+		switch {
+		case stale:
+			notFoundErr = ErrFlagConfigStale
+		case flags == nil:
+			notFoundErr = ErrFlagsNotLoaded
+		default:
+			notFoundErr = flags.notFound(flagKeys)
+		}
+	}
+	if flags.empty() {
 		c.log.Debug("evaluate: no flags")
-		return variants, nil
+		return make(map[string]experiment.Variant), notFoundErr
 
 	}
+	result, err := c.evaluate(flags.selection(c.engine, flagKeys), user)
+	if err != nil {
+		return nil, err
+	}
+	return toVariants(result, options.IncludeDefaultVariants), notFoundErr
+}
+
+// evaluate runs the configured engine over the selected flags.
+func (c *Client) evaluate(selection *flagSelection, user *experiment.User) (*evaluationResult, error) {
+	if c.engine == EvaluationEngineGo {
+		c.log.Debug("evaluate:\n\t- user: %v\n\t- flags: %v\n", user, len(selection.flags))
+		result := evaluation.EvaluateFlags(selection.flags, user)
+		c.log.Debug("evaluate result: %v\n", result)
+		return selection.filter(&result), nil
+	}
 	userJson, err := json.Marshal(user)
 	if err != nil {
 		return nil, err
 	}
 
-	c.log.Debug("evaluate:\n\t- user: %v\n\t- rules: %v\n", string(userJson), *c.flags)
+	c.log.Debug("evaluate:\n\t- user: %v\n\t- rules: %v\n", string(userJson), selection.rules)
 
-	resultJson := evaluation.Evaluate(*c.flags, string(userJson))
+	resultJson := evaluation.Evaluate(selection.rules, string(userJson))
 	c.log.Debug("evaluate result: %v\n", resultJson)
 	var interopResult *interopResult
 	err = json.Unmarshal([]byte(resultJson), &interopResult)
@@ -90,32 +350,54 @@ func (c *Client) Evaluate(user *experiment.User, flagKeys []string) (map[string]
 	if interopResult.Error != nil {
 		return nil, fmt.Errorf("evaluation resulted in error: %v", *interopResult.Error)
 	}
-	result := interopResult.Result
-	filter := len(flagKeys) != 0
+	return selection.filter(interopResult.Result), nil
+}
+
+func toVariants(result *evaluationResult, includeDefaults bool) map[string]experiment.Variant {
+	variants := make(map[string]experiment.Variant, len(*result))
 	for k, v := range *result {
-		if v.IsDefaultVariant || (filter && !contains(flagKeys, k)) {
+		if v.IsDefaultVariant && !includeDefaults {
 			continue
 		}
 		variants[k] = experiment.Variant{
-			Value:   v.Variant.Key,
-			Payload: v.Variant.Payload,
+			Value:            v.Variant.Key,
+			Payload:          v.Variant.Payload,
+			IsDefaultVariant: v.IsDefaultVariant,
 		}
 	}
-	return variants, nil
+	return variants
+}
+
+// Rules fetches the flag configs from sdk/rules, keyed by flag key. When flags
+// come from FlagConfigPath or FlagConfigSource, the configs are read from that
+// source instead and no request is sent to the flag server. Unknown fields are
+// ignored unless Config.StrictFlagDecoding is set, in which case they are
+// reported by the error of ParseFlags, returned alongside the flags.
+func (c *Client) Rules() (map[string]*Flag, error) {
+	return c.RulesContext(context.Background())
 }
 
-func (c *Client) Rules() (map[string]interface{}, error) {
-	return c.doRules()
+// RulesContext is Rules bounded by ctx as well as the configured request
+// timeout.
+func (c *Client) RulesContext(ctx context.Context) (map[string]*Flag, error) {
+	return c.doRules(ctx)
 }
 
-func (c *Client) doRules() (map[string]interface{}, error) {
+func (c *Client) doRules(ctx context.Context) (map[string]*Flag, error) {
+	if !needsApiKey(c.config) {
+		flags, err := c.FlagsContext(ctx)
+		if flags == nil {
+			return nil, err
+		}
+		return flagsByKey(flags), err
+	}
 	endpoint, err := url.Parse(c.config.ServerUrl)
 	if err != nil {
 		return nil, err
 	}
 	endpoint.Path = "sdk/rules"
 	endpoint.RawQuery = "eval_mode=local"
-	ctx, cancel := context.WithTimeout(context.Background(), c.config.FlagConfigPollerRequestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, c.config.FlagConfigPollerRequestTimeout)
 	defer cancel()
 	req, err := http.NewRequest("GET", endpoint.String(), nil)
 	if err != nil {
@@ -130,63 +412,171 @@ func (c *Client) doRules() (map[string]interface{}, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	err = validateFlagsResponse(resp)
+	if err != nil {
+		return nil, err
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 	c.log.Debug("rules: %v", string(body))
-	var rules []map[string]interface{}
-	err = json.Unmarshal(body, &rules)
-	if err != nil {
+	flags, err := decodeFlags(body, c.config.StrictFlagDecoding)
+	if flags == nil {
 		return nil, err
 	}
-	var result = make(map[string]interface{})
-	for _, rule := range rules {
-		flagKey := rule["flagKey"]
-		result[fmt.Sprintf("%v", flagKey)] = rule
+	return flagsByKey(flags), err
+}
+
+func flagsByKey(flags []*Flag) map[string]*Flag {
+	var result = make(map[string]*Flag, len(flags))
+	for _, flag := range flags {
+		result[flag.Key] = flag
 	}
-	return result, nil
+	return result
 }
 
-func (c *Client) Flags() (*string, error) {
-	return c.doFlags()
+// Flags fetches the flag configs from the flag config source and decodes
+// them like Rules.
+func (c *Client) Flags() ([]*Flag, error) {
+	return c.FlagsContext(context.Background())
 }
 
-func (c *Client) doFlags() (*string, error) {
-	endpoint, err := url.Parse(c.config.ServerUrl)
+// FlagsContext is Flags bounded by ctx as well as the configured request
+// timeout.
+func (c *Client) FlagsContext(ctx context.Context) ([]*Flag, error) {
+	flags, err := c.doFlags(ctx)
 	if err != nil {
 		return nil, err
 	}
-	endpoint.Path = "sdk/v1/flags"
-	ctx, cancel := context.WithTimeout(context.Background(), c.config.FlagConfigPollerRequestTimeout)
-	defer cancel()
-	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	return decodeFlags([]byte(*flags), c.config.StrictFlagDecoding)
+}
+
+// FlagsJSON fetches the flag configs from the flag config source without
+// decoding them.
+func (c *Client) FlagsJSON() (*string, error) {
+	return c.FlagsJSONContext(context.Background())
+}
+
+// FlagsJSONContext is FlagsJSON bounded by ctx as well as the configured
+// request timeout.
+func (c *Client) FlagsJSONContext(ctx context.Context) (*string, error) {
+	return c.doFlags(ctx)
+}
+
+// updateFlags fetches, parses and validates the flag config and makes it the
+// current flag set. Configs that fail validation are reported and leave the
+// current flags in place.
+func (c *Client) updateFlags(ctx context.Context) error {
+	err := c.doUpdateFlags(ctx)
+	c.stats.record(err)
+	c.status.record(err)
+	var unchanged *unchangedRejectionError
+	if errors.Is(err, ErrFlagConfigRejected) && !errors.As(err, &unchanged) {
+		c.rejectFlags(err)
+	}
+	return err
+}
+
+func (c *Client) doUpdateFlags(ctx context.Context) error {
+	c.stats.polls.Add(1)
+	config, err := c.source.Fetch(ctx, c.validators())
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req = req.WithContext(ctx)
-	req.Header.Set("Authorization", fmt.Sprintf("Api-Key %s", c.apiKey))
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
-	req.Header.Set("X-Amp-Exp-Library", fmt.Sprintf("experiment-go-server/%v", experiment.VERSION))
-	resp, err := c.client.Do(req)
+	if config.NotModified {
+		c.stats.notModified.Add(1)
+		if c.rejected != nil {
+			// The config is still the one that was rejected, so the flags
+			// being served remain out of date.
+			c.log.Debug("rejected flags not modified")
+			return &unchangedRejectionError{err: c.rejectedErr}
+		}
+		c.log.Debug("flags not modified")
+		// Record in the cache that the flags were confirmed fresh, so a
+		// cold start restores the time of this poll.
+		c.saveFlags(c.flags.Load())
+		return nil
+	}
+	c.log.Debug("flags: %v", string(config.Flags))
+	err = c.applyFlags(config)
+	c.rejected, c.rejectedErr = nil, nil
+	if errors.Is(err, ErrFlagConfigRejected) && (config.ETag != "" || config.LastModified != "") {
+		c.rejected = &FlagConfig{ETag: config.ETag, LastModified: config.LastModified}
+		c.rejectedErr = err
+	}
+	return err
+}
+
+// validators returns the validators to fetch the next flag config with:
+// those of the last rejected config, so that it is not fetched and rejected
+// again until it changes, or else those of the current flags. A poll answered
+// Not Modified for a rejected config fails with the original rejection.
+func (c *Client) validators() *FlagConfig {
+	flags := c.flags.Load()
+	if flags == nil {
+		return nil
+	}
+	if c.rejected != nil {
+		return c.rejected
+	}
+	return &FlagConfig{ETag: flags.etag, LastModified: flags.lastModified}
+}
+
+// applyFlags parses a fetched or streamed flag config and stores it.
+func (c *Client) applyFlags(config *FlagConfig) error {
+	result, err := parseFlagSet(config.Flags)
 	if err != nil {
-		return nil, err
+		return rejectedf("%v", err)
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	result.etag = config.ETag
+	result.lastModified = config.LastModified
+	err = c.storeFlags(result)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	flags := string(body)
-	c.log.Debug("flags: %v", flags)
-	return &flags, nil
+	c.saveFlags(result)
+	c.deliverFlagChanges()
+	return nil
 }
 
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
+// storeFlags validates flags against the current flag set and replaces it,
+// assigning flags the version following the one it replaces unless it already
+// has one, and queues the resulting flag change event.
+func (c *Client) storeFlags(flags *flagSet) error {
+	c.flagsMutex.Lock()
+	defer c.flagsMutex.Unlock()
+	current := c.flags.Load()
+	err := c.validateFlagSet(flags, current)
+	if err != nil {
+		return err
+	}
+	if flags.version == 0 {
+		flags.version = 1
+		if current != nil {
+			flags.version = current.version + 1
+		}
+	}
+	c.flags.Store(flags)
+	c.queueFlagChanges(current, flags)
+	if c.engine == EvaluationEngineGo {
+		for _, flag := range flags.flags {
+			if fields := flag.UnsupportedFields(); len(fields) != 0 {
+				c.log.Error("flag %s uses %s, which the Go evaluation engine ignores", flag.Key, strings.Join(fields, ", "))
+			}
 		}
 	}
-	return false
+	c.initOnce.Do(func() {
+		close(c.initialized)
+	})
+	return nil
+}
+
+func (c *Client) doFlags(ctx context.Context) (*string, error) {
+	config, err := c.source.Fetch(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	flags := string(config.Flags)
+	return &flags, nil
 }
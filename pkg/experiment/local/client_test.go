@@ -0,0 +1,114 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+func TestInitialFetchRetries(t *testing.T) {
+	tests := []struct {
+		background bool
+		want       int32
+	}{
+		{false, 1},
+		{true, 3},
+	}
+	for _, tt := range tests {
+		var requests atomic.Int32
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			panic(http.ErrAbortHandler)
+		})
+		client := newTestClient(t, &Config{
+			ServerUrl:                server.URL,
+			StartInBackground:        tt.background,
+			InitialFetchRetries:      2,
+			InitialFetchRetryBackoff: time.Millisecond,
+			FlagConfigPollerInterval: time.Hour,
+		})
+		err := client.Start()
+		if tt.background {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			err = client.WaitForInitialization(ctx)
+			cancel()
+		}
+		if err == nil {
+			t.Errorf("background %v: no error from a server that is down", tt.background)
+		}
+		if n := requests.Load(); n != tt.want {
+			t.Errorf("background %v: %d requests, want %d", tt.background, n, tt.want)
+		}
+	}
+}
+
+func TestCloseCancelsFetch(t *testing.T) {
+	started := make(chan struct{})
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	client := newTestClient(t, &Config{ServerUrl: server.URL, StartInBackground: true})
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Errorf("Close() = %v, want the in-flight fetch cancelled", err)
+	}
+}
+
+func TestCloseRemovesClient(t *testing.T) {
+	config := &Config{
+		FlagConfigSource: NewMemoryFlagConfigSource([]byte(testFlags)),
+		EvaluationEngine: EvaluationEngineGo,
+	}
+	client := Initialize("close", config)
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if Initialize("close", config) != client {
+		t.Fatal("Initialize() returned a new client for the same api key")
+	}
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	next := Initialize("close", config)
+	defer next.Close(context.Background())
+	if next == client {
+		t.Error("Initialize() returned the closed client")
+	}
+	_, err := client.Evaluate(&experiment.User{UserId: "u"}, nil)
+	if !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Evaluate() error = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestCloseFromListener(t *testing.T) {
+	source := NewMemoryFlagConfigSource([]byte(testFlags))
+	client := newTestClient(t, &Config{FlagConfigSource: source, FlagConfigPollerInterval: 10 * time.Millisecond})
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan error)
+	client.AddFlagChangeListener(func(FlagChangeEvent) {
+		closed <- client.Close(context.Background())
+	})
+	source.Set([]byte(strings.Replace(testFlags, `"enabled":false`, `"enabled":true`, 1)))
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close() from a listener did not return")
+	}
+}
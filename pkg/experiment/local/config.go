@@ -1,19 +1,135 @@
 package local
 
-import "time"
+import (
+	"net/http"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// EvaluationEngine selects the implementation used by Client.Evaluate.
+type EvaluationEngine int
+
+const (
+	// EvaluationEngineDefault uses the interop library. Builds without cgo
+	// must select EvaluationEngineGo explicitly.
+	EvaluationEngineDefault EvaluationEngine = iota
+	// EvaluationEngineInterop uses the Kotlin/Native library through cgo.
+	EvaluationEngineInterop
+	// EvaluationEngineGo uses the pure-Go engine and needs no cgo. It does
+	// not apply global holdbacks or mutual exclusion groups, and its bucketing
+	// has not been verified against the interop library.
+	EvaluationEngineGo
+)
 
 type Config struct {
 	Debug                          bool
 	ServerUrl                      string
 	FlagConfigPollerInterval       time.Duration
 	FlagConfigPollerRequestTimeout time.Duration
+	EvaluationEngine               EvaluationEngine
+	// HTTPClient sends the client's requests. When it is nil a client is
+	// built from HTTP.
+	HTTPClient *http.Client
+	HTTP       *experiment.HTTPConfig
+	// StartInBackground makes Start return at once and load the first flag
+	// config in the background. Use WaitForInitialization to wait for it.
+	StartInBackground bool
+	// InitialFetchRetries is how many times a failed first flag config fetch
+	// is retried with StartInBackground, waiting InitialFetchRetryBackoff
+	// before the first retry and doubling the wait after each one. A negative
+	// value disables retries. A blocking Start fetches once and leaves later
+	// attempts to the poller.
+	InitialFetchRetries      int
+	InitialFetchRetryBackoff time.Duration
+	// FlagConfigPollerJitter randomly moves each poll earlier or later by up
+	// to this fraction of the interval. A negative value disables jitter and
+	// values above 0.5 are lowered to 0.5, so that polls are never scheduled
+	// immediately.
+	FlagConfigPollerJitter float64
+	// FlagConfigPollerMaxBackoff caps the poll interval, which doubles after
+	// each consecutive failed poll and resets after a successful one.
+	FlagConfigPollerMaxBackoff time.Duration
+	// StreamUpdates subscribes to flag config updates over server-sent
+	// events, or to a FlagConfigSource that implements FlagConfigSubscriber.
+	// Polling is paused while the subscription is connected and resumes
+	// whenever it is not.
+	StreamUpdates bool
+	// StreamServerUrl is the stream server, defaulting to ServerUrl.
+	StreamServerUrl string
+	// StreamKeepAliveTimeout reconnects a stream that has been silent for
+	// this long.
+	StreamKeepAliveTimeout time.Duration
+	// StreamReconnectBackoffMin and StreamReconnectBackoffMax bound the
+	// delay between stream reconnection attempts, which doubles after each
+	// failed attempt.
+	StreamReconnectBackoffMin time.Duration
+	StreamReconnectBackoffMax time.Duration
+	// FlagConfigSource provides the flag configs instead of the flag server
+	// or FlagConfigPath. No api key is needed.
+	FlagConfigSource FlagConfigSource
+	// FlagConfigPath runs the client offline, reading flag configs in the
+	// sdk/v1/flags format from this file, or from the .json files in this
+	// directory, instead of the server. The files are checked for changes
+	// every FlagConfigWatchInterval and reloaded with the same validation as
+	// fetched configs. No api key is needed.
+	FlagConfigPath          string
+	FlagConfigWatchInterval time.Duration
+	// FlagConfigCachePath is a file where every accepted flag config is
+	// saved, and from which the last one is loaded when the client starts, so
+	// that flags are available before the first fetch succeeds.
+	FlagConfigCachePath string
+	// MaxFlagConfigAge is how long after the last successful update the flag
+	// config becomes stale. Zero never considers it stale.
+	MaxFlagConfigAge time.Duration
+	// StaleFlagConfigPolicy selects how evaluations behave once the flag
+	// config is stale.
+	StaleFlagConfigPolicy StalePolicy
+	// BatchEvaluationConcurrency bounds the workers used by EvaluateBatch.
+	// Zero uses GOMAXPROCS.
+	BatchEvaluationConcurrency int
+	// AllowEmptyFlagConfig lets a fetched config without flags replace a
+	// non-empty one.
+	AllowEmptyFlagConfig bool
+	// RejectEmptyInitialFlagConfig rejects a first flag config without flags,
+	// for deployments that always define flags, so that Start fails instead
+	// of serving defaults.
+	RejectEmptyInitialFlagConfig bool
+	// FlagConfigMaxShrink rejects a fetched config that removes more than
+	// this fraction of the current flags. Zero disables the check.
+	FlagConfigMaxShrink float64
+	// StrictFlagDecoding makes Rules and Flags decode with ParseFlags, so
+	// that fields missing from the Flag model are reported.
+	StrictFlagDecoding bool
+	// OnFlagConfigRejected is called with the reason whenever a fetched
+	// config fails validation and the current flags are kept.
+	OnFlagConfigRejected func(err error)
+}
+
+type EvaluateOptions struct {
+	// IncludeDefaultVariants returns flags that evaluated to their default
+	// variant instead of omitting them.
+	IncludeDefaultVariants bool
 }
 
+// maxPollerJitter is the largest FlagConfigPollerJitter, which keeps every
+// poll at least half an interval after the previous one.
+const maxPollerJitter = 0.5
+
 var DefaultConfig = &Config{
 	Debug:                          false,
 	ServerUrl:                      "https://api.lab.amplitude.com/",
 	FlagConfigPollerInterval:       30 * time.Second,
 	FlagConfigPollerRequestTimeout: 10 * time.Second,
+	EvaluationEngine:               EvaluationEngineDefault,
+	InitialFetchRetries:            2,
+	InitialFetchRetryBackoff:       500 * time.Millisecond,
+	FlagConfigWatchInterval:        1 * time.Second,
+	FlagConfigPollerJitter:         0.1,
+	FlagConfigPollerMaxBackoff:     5 * time.Minute,
+	StreamKeepAliveTimeout:         30 * time.Second,
+	StreamReconnectBackoffMin:      1 * time.Second,
+	StreamReconnectBackoffMax:      1 * time.Minute,
 }
 
 func fillConfigDefaults(c *Config) *Config {
@@ -29,5 +145,44 @@ func fillConfigDefaults(c *Config) *Config {
 	if c.FlagConfigPollerRequestTimeout == 0 {
 		c.FlagConfigPollerRequestTimeout = DefaultConfig.FlagConfigPollerRequestTimeout
 	}
+	if c.InitialFetchRetries == 0 {
+		c.InitialFetchRetries = DefaultConfig.InitialFetchRetries
+	}
+	if c.InitialFetchRetryBackoff == 0 {
+		c.InitialFetchRetryBackoff = DefaultConfig.InitialFetchRetryBackoff
+	}
+	if c.FlagConfigWatchInterval == 0 {
+		c.FlagConfigWatchInterval = DefaultConfig.FlagConfigWatchInterval
+	}
+	if c.FlagConfigPollerJitter == 0 {
+		c.FlagConfigPollerJitter = DefaultConfig.FlagConfigPollerJitter
+	}
+	if c.FlagConfigPollerJitter > maxPollerJitter {
+		c.FlagConfigPollerJitter = maxPollerJitter
+	}
+	if c.FlagConfigPollerMaxBackoff == 0 {
+		c.FlagConfigPollerMaxBackoff = DefaultConfig.FlagConfigPollerMaxBackoff
+	}
+	if c.StreamServerUrl == "" {
+		c.StreamServerUrl = c.ServerUrl
+	}
+	if c.StreamKeepAliveTimeout == 0 {
+		c.StreamKeepAliveTimeout = DefaultConfig.StreamKeepAliveTimeout
+	}
+	if c.StreamReconnectBackoffMin == 0 {
+		c.StreamReconnectBackoffMin = DefaultConfig.StreamReconnectBackoffMin
+	}
+	if c.StreamReconnectBackoffMax == 0 {
+		c.StreamReconnectBackoffMax = DefaultConfig.StreamReconnectBackoffMax
+	}
 	return c
 }
+
+func (e EvaluationEngine) resolve() EvaluationEngine {
+	if e != EvaluationEngineDefault {
+		return e
+	}
+	// The Go engine is not yet verified to bucket users like the interop
+	// library, so it is only used when selected explicitly.
+	return EvaluationEngineInterop
+}
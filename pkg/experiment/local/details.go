@@ -0,0 +1,153 @@
+package local
+
+import (
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// EvaluationReason explains why a flag evaluated to its variant.
+type EvaluationReason string
+
+const (
+	// ReasonTargeted means the user matched an inclusion list or a custom
+	// segment's conditions.
+	ReasonTargeted EvaluationReason = evaluation.ReasonTargeted
+	// ReasonRollout means the user was bucketed by the all-users segment.
+	ReasonRollout EvaluationReason = evaluation.ReasonRollout
+	// ReasonDefault means no segment assigned a variant and the flag's
+	// default variant was served.
+	ReasonDefault EvaluationReason = evaluation.ReasonDefault
+	// ReasonFlagOff means the flag is disabled.
+	ReasonFlagOff EvaluationReason = evaluation.ReasonFlagOff
+	// ReasonNotFound means the requested flag is not in the loaded config.
+	ReasonNotFound EvaluationReason = "not-found"
+	// ReasonPrerequisiteFailed means a prerequisite flag did not evaluate to a
+	// required variant.
+	ReasonPrerequisiteFailed EvaluationReason = evaluation.ReasonPrerequisiteFailed
+	// ReasonNotLoaded means no flag config has been loaded yet.
+	ReasonNotLoaded EvaluationReason = "not-loaded"
+	// ReasonStale means the flag config is stale and the stale policy is
+	// StalePolicyDefaults, so no flag was evaluated.
+	ReasonStale EvaluationReason = "stale"
+	// ReasonError means the flag could not be evaluated.
+	ReasonError EvaluationReason = "error"
+	// ReasonUnknown means the interop engine returned a description that
+	// does not map to any other reason. Use EvaluationEngineGo for reliable
+	// reasons.
+	ReasonUnknown EvaluationReason = "unknown"
+)
+
+type EvaluationDetails struct {
+	Variant          experiment.Variant
+	IsDefaultVariant bool
+	Reason           EvaluationReason
+	// Error describes the failure when Reason is ReasonError.
+	Error string
+	// Segment is the name of the matched segment and SegmentIndex its
+	// position: custom segments are numbered from 0 and the all-users segment
+	// follows them. SegmentIndex is -1 when no segment matched or when the
+	// interop engine, which does not report segments, was used.
+	Segment      string
+	SegmentIndex int
+	// Bucket is the user's allocation bucket in [0, 10000), or -1 if the user
+	// was not bucketed.
+	Bucket int
+	// Dependencies lists the prerequisite flags checked before this flag, in
+	// evaluation order. Only the Go engine reports it.
+	Dependencies []string
+	// FlagVersion is the version of the flag config the variant was
+	// evaluated against. It increases every time the client loads flags.
+	FlagVersion uint64
+}
+
+// EvaluateDetails evaluates flagKeys, or every loaded flag when flagKeys is
+// empty, and reports how each variant was chosen. Unlike Evaluate, default
+// variants are included and requested flags missing from the config are
+// reported with ReasonNotFound, or with ReasonNotLoaded or ReasonStale when no
+// flags are being served.
+//
+// Full details need EvaluationEngineGo. The interop library only describes
+// its results in its own words, which have not been checked against the
+// descriptions the reasons are derived from, so with the interop engine the
+// reason may be ReasonUnknown, SegmentIndex and Bucket are -1 and
+// Dependencies is empty.
+func (c *Client) EvaluateDetails(user *experiment.User, flagKeys []string) (map[string]EvaluationDetails, error) {
+	flags, stale, err := c.evaluationFlags()
+	if err != nil {
+		return nil, err
+	}
+	details := make(map[string]EvaluationDetails)
+	var version uint64
+	if flags != nil {
+		version = flags.version
+	}
+	reason := ReasonNotFound
+	if stale {
+		reason = ReasonStale
+	} else if flags == nil {
+		reason = ReasonNotLoaded
+	}
+	for _, key := range flagKeys {
+		if flags == nil || flags.index[key] == nil {
+			details[key] = EvaluationDetails{
+				Reason:       reason,
+				SegmentIndex: -1,
+				Bucket:       -1,
+				FlagVersion:  version,
+			}
+		}
+	}
+	if flags.empty() {
+		c.log.Debug("evaluate details: no flags")
+		return details, nil
+	}
+	selection := flags.selection(c.engine, flagKeys)
+	result, err := c.evaluate(selection, user)
+	if err != nil {
+		c.log.Error("evaluate details error: %v", err)
+		for _, flag := range selection.flags {
+			if selection.requested != nil && !selection.requested[flag.Key] {
+				continue
+			}
+			details[flag.Key] = EvaluationDetails{
+				Reason:       ReasonError,
+				Error:        err.Error(),
+				SegmentIndex: -1,
+				Bucket:       -1,
+				FlagVersion:  version,
+			}
+		}
+		return details, nil
+	}
+	for k, v := range *result {
+		details[k] = c.evaluationDetails(v, version)
+	}
+	return details, nil
+}
+
+func (c *Client) evaluationDetails(result flagResult, version uint64) EvaluationDetails {
+	d := EvaluationDetails{
+		Variant: experiment.Variant{
+			Value:            result.Variant.Key,
+			Payload:          result.Variant.Payload,
+			IsDefaultVariant: result.IsDefaultVariant,
+		},
+		IsDefaultVariant: result.IsDefaultVariant,
+		Reason:           EvaluationReason(result.Reason),
+		Segment:          result.Segment,
+		SegmentIndex:     result.SegmentIndex,
+		Bucket:           result.Bucket,
+		Dependencies:     result.Dependencies,
+		FlagVersion:      version,
+	}
+	if c.engine != EvaluationEngineGo {
+		// The interop library only reports a description of the outcome.
+		d.Reason = EvaluationReason(evaluation.ReasonForDescription(result.Description))
+		if d.Reason == "" {
+			d.Reason = ReasonUnknown
+		}
+		d.SegmentIndex = -1
+		d.Bucket = -1
+	}
+	return d
+}
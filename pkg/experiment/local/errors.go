@@ -0,0 +1,37 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrClientClosed is returned by a Client after Close has been called.
+var ErrClientClosed = errors.New("local evaluation client is closed")
+
+// ErrFlagConfigStale is returned by evaluations when the flag config is older
+// than Config.MaxFlagConfigAge and the stale policy is StalePolicyError. Under
+// StalePolicyDefaults, EvaluateWithOptions returns it alongside an empty
+// result when options.IncludeDefaultVariants is set.
+var ErrFlagConfigStale = errors.New("flag config is stale")
+
+// ErrFlagsNotLoaded is returned by EvaluateWithOptions, when
+// options.IncludeDefaultVariants is set, before any flag config is loaded.
+var ErrFlagsNotLoaded = errors.New("flag config is not loaded")
+
+// ErrFlagNotFound matches a *FlagsNotFoundError with errors.Is.
+var ErrFlagNotFound = errors.New("flag not found")
+
+// FlagsNotFoundError lists requested flag keys that are not in the loaded
+// flag config.
+type FlagsNotFoundError struct {
+	FlagKeys []string
+}
+
+func (e *FlagsNotFoundError) Error() string {
+	return fmt.Sprintf("flags not found: %s", strings.Join(e.FlagKeys, ", "))
+}
+
+func (e *FlagsNotFoundError) Is(target error) bool {
+	return target == ErrFlagNotFound
+}
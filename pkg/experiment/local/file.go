@@ -0,0 +1,124 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileFlagConfigSource reads flag configs from Path, which is a file in the
+// sdk/v1/flags format or a directory of such files with a .json extension,
+// merged in name order. Its ETag is derived from the files' names, sizes and
+// modification times, so unchanged files are not read again.
+type FileFlagConfigSource struct {
+	Path string
+
+	mutex sync.Mutex
+	// invalid is the signature of directory contents that could not be
+	// merged, and invalidReason the reason. They are rejected again without
+	// being read until they change.
+	invalid       string
+	invalidReason error
+}
+
+func (s *FileFlagConfigSource) Fetch(ctx context.Context, current *FlagConfig) (*FlagConfig, error) {
+	files, err := flagFiles(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := filesSignature(files)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.invalidErr(signature); err != nil {
+		return nil, &unchangedRejectionError{err: err}
+	}
+	if current != nil && current.ETag == signature {
+		return &FlagConfig{NotModified: true}, nil
+	}
+	if len(files) == 1 {
+		body, err := os.ReadFile(files[0])
+		if err != nil {
+			return nil, err
+		}
+		return &FlagConfig{Flags: body, ETag: signature}, nil
+	}
+	merged := []json.RawMessage{}
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			continue
+		}
+		var flags []json.RawMessage
+		err = json.Unmarshal(body, &flags)
+		if err != nil {
+			err = rejectedf("%s: %v", file, err)
+			s.setInvalid(signature, err)
+			return nil, err
+		}
+		merged = append(merged, flags...)
+	}
+	body, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	return &FlagConfig{Flags: body, ETag: signature}, nil
+}
+
+// invalidErr returns why the contents with signature could not be merged, or
+// nil if they were not found invalid.
+func (s *FileFlagConfigSource) invalidErr(signature string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.invalid != signature {
+		return nil
+	}
+	return s.invalidReason
+}
+
+func (s *FileFlagConfigSource) setInvalid(signature string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.invalid = signature
+	s.invalidReason = err
+}
+
+// flagFiles returns path if it is a file, or the .json files in it, sorted
+// by name, if it is a directory.
+func flagFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// filesSignature identifies the current contents of files without reading
+// them.
+func filesSignature(files []string) (string, error) {
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
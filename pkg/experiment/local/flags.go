@@ -0,0 +1,191 @@
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
+)
+
+// flagSet is a decoded sdk/v1/flags response, indexed by flag key so that
+// evaluating a flag only touches that flag's rules. A flag set is immutable
+// once a client has stored it.
+type flagSet struct {
+	// version increases by one with every flag set a client stores. A set
+	// loaded from the cache keeps its cached version.
+	version uint64
+	raw     string
+	flags   []*evaluation.Flag
+	index   map[string]*evaluation.Flag
+	rules   map[string]json.RawMessage
+	// order is each flag's position in flags, which lists prerequisites
+	// before their dependents.
+	order map[string]int
+	// etag and lastModified are the validators of the response the set was
+	// parsed from, sent with the next poll to skip unchanged configs.
+	etag         string
+	lastModified string
+}
+
+func parseFlagSet(body []byte) (*flagSet, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, errors.New("flag config is not a JSON array")
+	}
+	var rawFlags []json.RawMessage
+	err := json.Unmarshal(body, &rawFlags)
+	if err != nil {
+		return nil, err
+	}
+	set := &flagSet{
+		raw:   string(body),
+		flags: make([]*evaluation.Flag, 0, len(rawFlags)),
+		index: make(map[string]*evaluation.Flag, len(rawFlags)),
+		rules: make(map[string]json.RawMessage, len(rawFlags)),
+	}
+	for _, rawFlag := range rawFlags {
+		var flag *evaluation.Flag
+		err = json.Unmarshal(rawFlag, &flag)
+		if err != nil {
+			return nil, err
+		}
+		if flag == nil {
+			continue
+		}
+		if flag.Key == "" {
+			return nil, errors.New("flag without flagKey")
+		}
+		if set.index[flag.Key] != nil {
+			return nil, fmt.Errorf("duplicate flag %s", flag.Key)
+		}
+		set.flags = append(set.flags, flag)
+		set.index[flag.Key] = flag
+		set.rules[flag.Key] = rawFlag
+	}
+	sorted, err := evaluation.SortFlags(set.flags)
+	if err != nil {
+		return nil, err
+	}
+	evaluation.Prepare(sorted)
+	set.flags = sorted
+	set.order = make(map[string]int, len(sorted))
+	for i, flag := range sorted {
+		set.order[flag.Key] = i
+	}
+	return set, nil
+}
+
+func (s *flagSet) empty() bool {
+	return s == nil || len(s.flags) == 0
+}
+
+// lookup returns the flag with key, or nil if the set is nil or has no such
+// flag.
+func (s *flagSet) lookup(key string) *evaluation.Flag {
+	if s == nil {
+		return nil
+	}
+	return s.index[key]
+}
+
+// notFound returns a *FlagsNotFoundError naming the flagKeys missing from the
+// set, or nil if all of them are present.
+func (s *flagSet) notFound(flagKeys []string) error {
+	var missing []string
+	for _, key := range flagKeys {
+		if s.lookup(key) == nil {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &FlagsNotFoundError{FlagKeys: missing}
+}
+
+// withDependencies returns the loaded flags among flagKeys together with
+// their transitive prerequisites, ordered with prerequisites first.
+func (s *flagSet) withDependencies(flagKeys []string) []*evaluation.Flag {
+	seen := make(map[string]bool, len(flagKeys))
+	var flags []*evaluation.Flag
+	var add func(key string)
+	add = func(key string) {
+		flag := s.index[key]
+		if flag == nil || seen[key] {
+			return
+		}
+		seen[key] = true
+		flags = append(flags, flag)
+		if flag.ParentDependencies != nil {
+			for parent := range flag.ParentDependencies.Flags {
+				add(parent)
+			}
+		}
+	}
+	for _, key := range flagKeys {
+		add(key)
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return s.order[flags[i].Key] < s.order[flags[j].Key]
+	})
+	return flags
+}
+
+// selectRules returns the interop rules JSON for flags, built from the
+// original per-flag JSON rather than re-encoding it.
+func (s *flagSet) selectRules(flags []*evaluation.Flag) string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, flag := range flags {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(s.rules[flag.Key])
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// flagSelection is the part of a flag set that an evaluation runs over,
+// prepared once so it can be reused for many users. It includes the
+// prerequisites of the requested flags; requested is nil when every flag was
+// requested.
+type flagSelection struct {
+	flags     []*evaluation.Flag
+	rules     string
+	requested map[string]bool
+}
+
+// selection prepares the flags named by flagKeys, or every flag when flagKeys
+// is empty. Unknown keys are skipped.
+func (s *flagSet) selection(engine EvaluationEngine, flagKeys []string) *flagSelection {
+	if len(flagKeys) == 0 {
+		return &flagSelection{flags: s.flags, rules: s.raw}
+	}
+	selection := &flagSelection{
+		flags:     s.withDependencies(flagKeys),
+		requested: make(map[string]bool, len(flagKeys)),
+	}
+	for _, key := range flagKeys {
+		selection.requested[key] = true
+	}
+	if engine != EvaluationEngineGo {
+		selection.rules = s.selectRules(selection.flags)
+	}
+	return selection
+}
+
+// filter drops the results of prerequisites that were not requested.
+func (s *flagSelection) filter(result *evaluationResult) *evaluationResult {
+	if s.requested == nil || result == nil {
+		return result
+	}
+	for key := range *result {
+		if !s.requested[key] {
+			delete(*result, key)
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package local
+
+import (
+	"sync"
+	"time"
+)
+
+// StalePolicy selects how a client evaluates flags once its flag config is
+// older than Config.MaxFlagConfigAge.
+type StalePolicy int
+
+const (
+	// StalePolicyServe keeps evaluating the stale flags.
+	StalePolicyServe StalePolicy = iota
+	// StalePolicyDefaults evaluates as if no flags were loaded, so callers
+	// fall back to their defaults.
+	StalePolicyDefaults
+	// StalePolicyError fails evaluations with ErrFlagConfigStale.
+	StalePolicyError
+)
+
+// updateStatus records the outcome of the latest flag config updates.
+type updateStatus struct {
+	mutex       sync.RWMutex
+	lastSuccess time.Time
+	lastError   error
+}
+
+func (s *updateStatus) record(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastError = err
+	if err == nil {
+		s.lastSuccess = time.Now()
+	}
+}
+
+// restore sets the last successful update to when a cached flag config was
+// fetched.
+func (s *updateStatus) restore(fetchedAt time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastSuccess = fetchedAt
+}
+
+// Ready reports whether the client has loaded a flag config that is not
+// stale and has not been closed. It is meant for readiness probes.
+func (c *Client) Ready() bool {
+	return !c.closed.Load() && c.flags.Load() != nil && !c.Stale()
+}
+
+// Stale reports whether the flag config was last updated longer ago than
+// Config.MaxFlagConfigAge. Flags are never stale when MaxFlagConfigAge is
+// zero, nor while the flag stream is connected and its latest update was
+// accepted. Once a streamed config is rejected, staleness is measured from
+// the last accepted update again.
+func (c *Client) Stale() bool {
+	if c.config.MaxFlagConfigAge <= 0 {
+		return false
+	}
+	c.status.mutex.RLock()
+	last, lastErr := c.status.lastSuccess, c.status.lastError
+	c.status.mutex.RUnlock()
+	if c.streaming.Load() && lastErr == nil && !last.IsZero() {
+		return false
+	}
+	return last.IsZero() || time.Since(last) > c.config.MaxFlagConfigAge
+}
+
+// LastSuccessfulUpdate returns when the flag config was last fetched or
+// confirmed unchanged, or the zero time if it never was.
+func (c *Client) LastSuccessfulUpdate() time.Time {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return c.status.lastSuccess
+}
+
+// LastError returns the error of the latest flag config update, or nil if it
+// succeeded.
+func (c *Client) LastError() error {
+	c.status.mutex.RLock()
+	defer c.status.mutex.RUnlock()
+	return c.status.lastError
+}
+
+// evaluationFlags returns the flags to evaluate against after applying the
+// stale policy. stale is set when defaults are served because the flags are
+// stale, in which case flags is nil.
+func (c *Client) evaluationFlags() (flags *flagSet, stale bool, err error) {
+	if c.closed.Load() {
+		return nil, false, ErrClientClosed
+	}
+	flags = c.flags.Load()
+	if flags == nil || c.config.StaleFlagConfigPolicy == StalePolicyServe || !c.Stale() {
+		return flags, false, nil
+	}
+	if c.config.StaleFlagConfigPolicy == StalePolicyError {
+		return nil, false, ErrFlagConfigStale
+	}
+	c.log.Debug("flags are stale, serving defaults")
+	return nil, true, nil
+}
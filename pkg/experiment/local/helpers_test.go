@@ -0,0 +1,85 @@
+package local
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testFlags = `[
+	{"flagKey":"f","enabled":true,"bucketingSalt":"s","variants":[{"key":"on"}],
+	 "allUsersTargetingConfig":{"name":"all","conditions":[],"allocations":[{"percentage":10000,"weights":{"on":1}}]},
+	 "customSegmentTargetingConfigs":[]},
+	{"flagKey":"g","enabled":false,"bucketingSalt":"s","variants":[{"key":"on"}],
+	 "allUsersTargetingConfig":{"name":"all","conditions":[],"allocations":[{"percentage":10000,"weights":{"on":1}}]},
+	 "customSegmentTargetingConfigs":[]}
+]`
+
+// testServer is a flag server whose handler can be replaced while it runs.
+type testServer struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	handler http.HandlerFunc
+}
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	s := &testServer{handler: handler}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		handler := s.handler
+		s.mutex.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testServer) setHandler(handler http.HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handler = handler
+}
+
+// serveFlags answers every request with body.
+func serveFlags(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}
+}
+
+// newTestClient starts a client with the Go engine and no retries or jitter,
+// closing it when the test ends.
+func newTestClient(t *testing.T, config *Config) *Client {
+	t.Helper()
+	config.EvaluationEngine = EvaluationEngineGo
+	if config.InitialFetchRetries == 0 {
+		config.InitialFetchRetries = -1
+	}
+	if config.FlagConfigPollerJitter == 0 {
+		config.FlagConfigPollerJitter = -1
+	}
+	client, err := New("key", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close(context.Background())
+	})
+	return client
+}
+
+// waitFor polls condition until it holds or a second has passed.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,150 @@
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Flag is a flag configuration as served by sdk/v1/flags and sdk/rules.
+type Flag struct {
+	Key                           string              `json:"flagKey"`
+	Name                          string              `json:"flagName,omitempty"`
+	Version                       int                 `json:"flagVersion,omitempty"`
+	Type                          string              `json:"type,omitempty"`
+	EvalMode                      string              `json:"evalMode,omitempty"`
+	Deployed                      bool                `json:"deployed,omitempty"`
+	UserProperty                  string              `json:"userProperty,omitempty"`
+	Enabled                       bool                `json:"enabled"`
+	Description                   string              `json:"description,omitempty"`
+	BucketingKey                  string              `json:"bucketingKey,omitempty"`
+	BucketingSalt                 string              `json:"bucketingSalt,omitempty"`
+	DefaultValue                  *string             `json:"defaultValue,omitempty"`
+	Variants                      []*FlagVariant      `json:"variants,omitempty"`
+	AllUsersTargetingConfig       *Segment            `json:"allUsersTargetingConfig,omitempty"`
+	CustomSegmentTargetingConfigs []*Segment          `json:"customSegmentTargetingConfigs,omitempty"`
+	VariantsInclusions            map[string][]string `json:"variantsInclusions,omitempty"`
+	VariantsExclusions            map[string][]string `json:"variantsExclusions,omitempty"`
+	ParentDependencies            *ParentDependencies `json:"parentDependencies,omitempty"`
+	GlobalHoldbackPct             float64             `json:"globalHoldbackPct,omitempty"`
+	GlobalHoldbackSalt            string              `json:"globalHoldbackSalt,omitempty"`
+	GlobalHoldbackBucketingKey    string              `json:"globalHoldbackBucketingKey,omitempty"`
+	// MutualExclusionConfig is passed through as decoded, since its fields
+	// are not part of the model.
+	MutualExclusionConfig map[string]interface{} `json:"mutualExclusionConfig,omitempty"`
+}
+
+// ParentDependencies lists the prerequisite flags of a Flag. Flags maps each
+// prerequisite flag key to the variants that satisfy it; Operator is "all"
+// (the default) or "any".
+type ParentDependencies struct {
+	Flags    map[string][]string `json:"flags"`
+	Operator string              `json:"operator,omitempty"`
+}
+
+// FlagVariant is a variant a Flag can serve, with its payload.
+type FlagVariant struct {
+	Key     string      `json:"key"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// Segment targets the users matching its Conditions and allocates them to
+// variants.
+type Segment struct {
+	Name         string        `json:"name,omitempty"`
+	BucketingKey string        `json:"bucketingKey,omitempty"`
+	Conditions   []*Condition  `json:"conditions,omitempty"`
+	Allocations  []*Allocation `json:"allocations,omitempty"`
+}
+
+// Condition matches a user property against values with an operator.
+type Condition struct {
+	Prop   string   `json:"prop"`
+	Op     string   `json:"op"`
+	Values []string `json:"values,omitempty"`
+}
+
+// Allocation assigns a percentage of a segment's users, in hundredths of a
+// percent, to variants by weight.
+type Allocation struct {
+	Percentage int            `json:"percentage"`
+	Weights    map[string]int `json:"weights,omitempty"`
+}
+
+// FlagDecodeError reports a flag that does not match the Flag model, because
+// it has an unknown field or a field of the wrong type.
+type FlagDecodeError struct {
+	// Index is the flag's position in the config and FlagKey its key, if it
+	// could be read.
+	Index   int
+	FlagKey string
+	Err     error
+}
+
+func (e *FlagDecodeError) Error() string {
+	if e.FlagKey == "" {
+		return fmt.Sprintf("flag %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("flag %d (%s): %v", e.Index, e.FlagKey, e.Err)
+}
+
+func (e *FlagDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFlags strictly decodes a flag config in the sdk/v1/flags format. Every
+// flag with unknown or malformed fields is reported by a *FlagDecodeError,
+// joined into the returned error. The flags are returned even when the error
+// is not nil: a flag with unknown fields is included without them, and only
+// a flag with malformed fields is left out.
+func ParseFlags(data []byte) ([]*Flag, error) {
+	var rawFlags []json.RawMessage
+	err := json.Unmarshal(data, &rawFlags)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]*Flag, 0, len(rawFlags))
+	var errs []error
+	for i, rawFlag := range rawFlags {
+		decoder := json.NewDecoder(bytes.NewReader(rawFlag))
+		decoder.DisallowUnknownFields()
+		var flag *Flag
+		err := decoder.Decode(&flag)
+		if err != nil {
+			var key struct {
+				FlagKey string `json:"flagKey"`
+			}
+			_ = json.Unmarshal(rawFlag, &key)
+			errs = append(errs, &FlagDecodeError{Index: i, FlagKey: key.FlagKey, Err: err})
+			flag = nil
+			if json.Unmarshal(rawFlag, &flag) != nil {
+				continue
+			}
+		}
+		if flag != nil {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, errors.Join(errs...)
+}
+
+// decodeFlags decodes a flag config with ParseFlags if strict is set, and
+// ignoring unknown fields otherwise.
+func decodeFlags(data []byte, strict bool) ([]*Flag, error) {
+	if strict {
+		return ParseFlags(data)
+	}
+	var decoded []*Flag
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]*Flag, 0, len(decoded))
+	for _, flag := range decoded {
+		if flag != nil {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
@@ -0,0 +1,79 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/rules.json is a representative sdk/rules response with every field
+// the flag server sends; it was written by hand, not captured.
+func TestParseFlagsRules(t *testing.T) {
+	data, err := os.ReadFile("testdata/rules.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags, err := ParseFlags(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flags) != 2 {
+		t.Fatalf("ParseFlags returned %d flags, want 2", len(flags))
+	}
+	flag := flags[0]
+	if flag.Key != "checkout-redesign" || flag.Name != "Checkout redesign" || flag.Version != 14 ||
+		flag.Type != "experiment" || flag.EvalMode != "LOCAL" || !flag.Deployed ||
+		flag.UserProperty != "[Experiment] checkout-redesign" || flag.GlobalHoldbackSalt != "uHx0bVNp" {
+		t.Errorf("unexpected flag %+v", flag)
+	}
+	if deps := flags[1].ParentDependencies; deps == nil || len(deps.Flags["checkout-redesign"]) != 1 {
+		t.Errorf("ParentDependencies = %+v", deps)
+	}
+}
+
+func TestParseFlagsUnknownField(t *testing.T) {
+	flags, err := ParseFlags([]byte(`[
+		{"flagKey":"a","enabled":true},
+		{"flagKey":"b","enabled":true,"unknown":1},
+		{"flagKey":"c","enabled":"yes"}
+	]`))
+	var decodeErr *FlagDecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Index != 1 || decodeErr.FlagKey != "b" {
+		t.Errorf("ParseFlags error = %v, want a *FlagDecodeError for flag b", err)
+	}
+	if !strings.Contains(err.Error(), "flag 2 (c)") {
+		t.Errorf("ParseFlags error = %v, want flag c reported", err)
+	}
+	if len(flags) != 2 || flags[0].Key != "a" || flags[1].Key != "b" {
+		t.Errorf("ParseFlags returned %d flags, want a and b", len(flags))
+	}
+}
+
+func TestRulesUnknownField(t *testing.T) {
+	body := `[{"flagKey":"a","enabled":true,"newServerField":{}}]`
+	for _, strict := range []bool{false, true} {
+		server := newTestServer(t, serveFlags(body))
+		client := newTestClient(t, &Config{ServerUrl: server.URL, StrictFlagDecoding: strict})
+		rules, err := client.Rules()
+		if (err != nil) != strict {
+			t.Errorf("strict %v: Rules() error = %v", strict, err)
+		}
+		if rules["a"] == nil || !rules["a"].Enabled {
+			t.Errorf("strict %v: Rules() = %v, want flag a", strict, rules)
+		}
+	}
+}
+
+func TestRulesErrorResponse(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "[]", http.StatusServiceUnavailable)
+	})
+	client := newTestClient(t, &Config{ServerUrl: server.URL})
+	_, err := client.RulesContext(context.Background())
+	if !errors.Is(err, ErrFlagConfigRejected) {
+		t.Errorf("RulesContext error = %v, want ErrFlagConfigRejected", err)
+	}
+}
@@ -1,28 +1,88 @@
 package local
 
-import "time"
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
 
 type poller struct {
-	shutdown chan bool
+	wg sync.WaitGroup
+	// jitter is the fraction of each delay by which it is randomly shortened
+	// or lengthened, so that clients started together do not poll together.
+	jitter float64
+	// maxBackoff caps the delay after consecutive failures.
+	maxBackoff time.Duration
 }
 
-func newPoller() *poller {
-	return &poller{
-		shutdown: make(chan bool),
-	}
+func newPoller(jitter float64, maxBackoff time.Duration) *poller {
+	return &poller{jitter: jitter, maxBackoff: maxBackoff}
 }
 
-func (p *poller) Poll(interval time.Duration, function func()) {
-	ticker := time.NewTicker(interval)
+// Poll calls function about every interval until ctx is done, doubling the
+// delay after each consecutive failure up to maxBackoff. Calls never overlap.
+// The context passed to function is ctx, so cancelling it also cancels the
+// in-flight call.
+func (p *poller) Poll(ctx context.Context, interval time.Duration, function func(ctx context.Context) error) {
+	p.wg.Add(1)
 	go func() {
+		defer p.wg.Done()
+		failures := 0
+		timer := time.NewTimer(p.delay(interval, failures))
+		defer timer.Stop()
 		for {
 			select {
-			case <-p.shutdown:
-				ticker.Stop()
+			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				go function()
+			case <-timer.C:
+			}
+			if err := function(ctx); err != nil {
+				failures++
+			} else {
+				failures = 0
 			}
+			timer.Reset(p.delay(interval, failures))
 		}
 	}()
 }
+
+// delay returns the jittered time to wait before the next call.
+func (p *poller) delay(interval time.Duration, failures int) time.Duration {
+	d := interval
+	for i := 0; i < failures && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.maxBackoff && p.maxBackoff > interval {
+		d = p.maxBackoff
+	}
+	if p.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.jitter * float64(d))
+	}
+	return d
+}
+
+// Go runs function in a goroutine that Wait waits for.
+func (p *poller) Go(function func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		function()
+	}()
+}
+
+// Wait blocks until the polling loop and all in-flight calls have returned,
+// or until ctx is done.
+func (p *poller) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,27 @@
+package local
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollerJitterClamped(t *testing.T) {
+	for _, jitter := range []float64{0.1, 0.5, 1, 3} {
+		config := fillConfigDefaults(&Config{FlagConfigPollerJitter: jitter})
+		p := newPoller(config.FlagConfigPollerJitter, config.FlagConfigPollerMaxBackoff)
+		for i := 0; i < 1000; i++ {
+			if d := p.delay(time.Second, 0); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+				t.Fatalf("jitter %v: delay %v outside [0.5s, 1.5s]", jitter, d)
+			}
+		}
+	}
+}
+
+func TestPollerBackoff(t *testing.T) {
+	p := newPoller(-1, 8*time.Second)
+	for failures, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		if d := p.delay(time.Second, failures); d != want {
+			t.Errorf("delay after %d failures = %v, want %v", failures, d, want)
+		}
+	}
+}
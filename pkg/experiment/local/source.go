@@ -0,0 +1,121 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FlagConfig is a snapshot of the flag config returned by a FlagConfigSource.
+type FlagConfig struct {
+	// Flags is the flag config in the sdk/v1/flags JSON format.
+	Flags []byte
+	// ETag and LastModified optionally identify the snapshot, so that a
+	// source can tell when it is asked for a config it already returned.
+	ETag         string
+	LastModified string
+	// NotModified reports that the config is the one identified by the
+	// validators passed to Fetch. Flags is not set.
+	NotModified bool
+}
+
+// FlagConfigSource provides the flag configs evaluated by a Client, see
+// Config.FlagConfigSource.
+type FlagConfigSource interface {
+	// Fetch returns the current flag config. When current is not nil it
+	// holds the ETag and LastModified of the config the client already has,
+	// and Fetch may return a FlagConfig with NotModified set if that config
+	// is unchanged.
+	Fetch(ctx context.Context, current *FlagConfig) (*FlagConfig, error)
+}
+
+// FlagConfigSubscriber is implemented by sources that push flag configs. A
+// client subscribes when Config.StreamUpdates is set.
+type FlagConfigSubscriber interface {
+	// Subscribe calls connected once the subscription is established and
+	// update with every flag config received, until ctx is done or the
+	// subscription fails. It always returns a non-nil error.
+	Subscribe(ctx context.Context, connected func(), update func(*FlagConfig)) error
+}
+
+// MemoryFlagConfigSource serves a flag config held in memory, such as one
+// built by a test or received by other means. Subscribers receive every
+// config passed to Set.
+type MemoryFlagConfigSource struct {
+	mutex   sync.Mutex
+	flags   []byte
+	version int
+	// changed is closed and replaced by Set.
+	changed chan struct{}
+}
+
+func NewMemoryFlagConfigSource(flags []byte) *MemoryFlagConfigSource {
+	return &MemoryFlagConfigSource{flags: flags, changed: make(chan struct{})}
+}
+
+// Set replaces the flag config.
+func (s *MemoryFlagConfigSource) Set(flags []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.flags = flags
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *MemoryFlagConfigSource) snapshot() (*FlagConfig, chan struct{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return &FlagConfig{Flags: s.flags, ETag: fmt.Sprintf("%p-%d", s, s.version)}, s.changed
+}
+
+func (s *MemoryFlagConfigSource) Fetch(ctx context.Context, current *FlagConfig) (*FlagConfig, error) {
+	config, _ := s.snapshot()
+	if current != nil && current.ETag == config.ETag {
+		return &FlagConfig{NotModified: true}, nil
+	}
+	return config, nil
+}
+
+func (s *MemoryFlagConfigSource) Subscribe(ctx context.Context, connected func(), update func(*FlagConfig)) error {
+	connected()
+	for {
+		config, changed := s.snapshot()
+		update(config)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// FallbackFlagConfigSource fetches from each of its sources in turn and
+// returns the first config fetched successfully.
+type FallbackFlagConfigSource struct {
+	sources []FlagConfigSource
+}
+
+func NewFallbackFlagConfigSource(sources ...FlagConfigSource) *FallbackFlagConfigSource {
+	return &FallbackFlagConfigSource{sources: sources}
+}
+
+// Fetch returns the errors of all sources, joined, if none succeeds.
+func (s *FallbackFlagConfigSource) Fetch(ctx context.Context, current *FlagConfig) (*FlagConfig, error) {
+	if len(s.sources) == 0 {
+		return nil, errors.New("no flag config sources")
+	}
+	var errs []error
+	for _, source := range s.sources {
+		config, err := source.Fetch(ctx, current)
+		if err == nil {
+			return config, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,183 @@
+package local
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// HTTPFlagConfigSource fetches flag configs from the sdk/v1/flags endpoint of
+// a flag server and streams them from sdk/stream/v1/flags as server-sent
+// events. It is the source used by default.
+type HTTPFlagConfigSource struct {
+	ServerUrl string
+	// StreamServerUrl is the stream server, defaulting to ServerUrl.
+	StreamServerUrl string
+	ApiKey          string
+	// Client sends the requests. Nil uses http.DefaultClient. Its Timeout
+	// bounds fetches but is not applied to the stream, which stays open
+	// indefinitely and is bounded by StreamKeepAliveTimeout instead.
+	Client *http.Client
+	// RequestTimeout bounds each fetch. Zero means no timeout.
+	RequestTimeout time.Duration
+	// StreamKeepAliveTimeout ends a stream that has been silent for this
+	// long. Zero disables the check.
+	StreamKeepAliveTimeout time.Duration
+}
+
+func (s *HTTPFlagConfigSource) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+// streamClient is client without its Timeout, which would otherwise end every
+// stream after that long.
+func (s *HTTPFlagConfigSource) streamClient() *http.Client {
+	client := s.client()
+	if client.Timeout == 0 {
+		return client
+	}
+	stream := *client
+	stream.Timeout = 0
+	return &stream
+}
+
+func (s *HTTPFlagConfigSource) newRequest(ctx context.Context, serverUrl string, path string) (*http.Request, error) {
+	endpoint, err := url.Parse(serverUrl)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Path = path
+	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", fmt.Sprintf("Api-Key %s", s.ApiKey))
+	req.Header.Set("X-Amp-Exp-Library", fmt.Sprintf("experiment-go-server/%v", experiment.VERSION))
+	return req, nil
+}
+
+// Fetch requests the flag config, conditionally on current's validators when
+// current is set, and accepts a gzip encoded response.
+func (s *HTTPFlagConfigSource) Fetch(ctx context.Context, current *FlagConfig) (*FlagConfig, error) {
+	if s.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.RequestTimeout)
+		defer cancel()
+	}
+	req, err := s.newRequest(ctx, s.ServerUrl, "sdk/v1/flags")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Accept-Encoding", "gzip")
+	if current != nil && current.ETag != "" {
+		req.Header.Set("If-None-Match", current.ETag)
+	}
+	if current != nil && current.LastModified != "" {
+		req.Header.Set("If-Modified-Since", current.LastModified)
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && current != nil {
+		return &FlagConfig{NotModified: true}, nil
+	}
+	err = validateFlagsResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader = resp.Body
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &FlagConfig{
+		Flags:        body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// Subscribe opens the flag stream and passes every flag config it receives
+// to update until the connection fails.
+func (s *HTTPFlagConfigSource) Subscribe(ctx context.Context, connected func(), update func(*FlagConfig)) error {
+	serverUrl := s.StreamServerUrl
+	if serverUrl == "" {
+		serverUrl = s.ServerUrl
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := s.newRequest(ctx, serverUrl, "sdk/stream/v1/flags")
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	// The keep-alive timer cancels the request once the stream, or the
+	// attempt to connect, has been silent for too long.
+	keepAlive := s.StreamKeepAliveTimeout
+	if keepAlive <= 0 {
+		keepAlive = time.Duration(1<<63 - 1)
+	}
+	timer := time.AfterFunc(keepAlive, cancel)
+	defer timer.Stop()
+	resp, err := s.streamClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("stream request resulted in error response %v", resp.StatusCode)
+	}
+	connected()
+	reader := bufio.NewReader(resp.Body)
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// Stop reports false once the timer has fired.
+			if ctx.Err() != nil && !timer.Stop() {
+				return fmt.Errorf("no data received for %v", keepAlive)
+			}
+			return err
+		}
+		timer.Reset(keepAlive)
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			// A blank line ends an event.
+			if body := strings.TrimSpace(data.String()); body != "" {
+				update(&FlagConfig{Flags: []byte(body)})
+			}
+			data.Reset()
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package local
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testLastModified = "Mon, 12 Oct 2026 08:00:00 GMT"
+
+// conditionalHandler serves testFlags with validators and answers 304 when the
+// request carries both of them.
+func conditionalHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdk/v1/flags" || r.Header.Get("Authorization") != "Api-Key key" {
+			t.Errorf("unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == testLastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", testLastModified)
+		w.Write([]byte(testFlags))
+	}
+}
+
+func TestHTTPSourceConditionalFetch(t *testing.T) {
+	server := newTestServer(t, conditionalHandler(t))
+	source := &HTTPFlagConfigSource{ServerUrl: server.URL, ApiKey: "key"}
+	config, err := source.Fetch(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.NotModified || string(config.Flags) != testFlags {
+		t.Errorf("first fetch = %+v, want the flags", config)
+	}
+	if config.ETag != `"v1"` || config.LastModified != testLastModified {
+		t.Errorf("validators = %q %q", config.ETag, config.LastModified)
+	}
+	config, err = source.Fetch(context.Background(), config)
+	if err != nil || !config.NotModified {
+		t.Errorf("conditional fetch = %+v, %v, want not modified", config, err)
+	}
+	config, err = source.Fetch(context.Background(), &FlagConfig{ETag: `"v0"`, LastModified: testLastModified})
+	if err != nil || config.NotModified || string(config.Flags) != testFlags {
+		t.Errorf("fetch with stale validators = %+v, %v, want the flags", config, err)
+	}
+}
+
+func TestHTTPSourceNotModifiedWithoutCurrent(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	source := &HTTPFlagConfigSource{ServerUrl: server.URL, ApiKey: "key"}
+	_, err := source.Fetch(context.Background(), nil)
+	if !errors.Is(err, ErrFlagConfigRejected) {
+		t.Errorf("Fetch() error = %v, want ErrFlagConfigRejected", err)
+	}
+}
+
+func TestHTTPSourceGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write([]byte(testFlags))
+	writer.Close()
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(compressed.Bytes())
+	})
+	source := &HTTPFlagConfigSource{ServerUrl: server.URL, ApiKey: "key"}
+	config, err := source.Fetch(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(config.Flags) != testFlags {
+		t.Errorf("Fetch() flags = %q, want the decompressed flags", config.Flags)
+	}
+}
+
+func TestNotModifiedStats(t *testing.T) {
+	server := newTestServer(t, conditionalHandler(t))
+	client := newTestClient(t, &Config{ServerUrl: server.URL, FlagConfigPollerInterval: time.Hour})
+	err := client.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := client.FlagConfigVersion()
+	for i := 0; i < 2; i++ {
+		err = client.updateFlags(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := client.PollerStats()
+	want := PollerStats{Polls: 3, NotModified: 2, Successes: 3}
+	if stats != want {
+		t.Errorf("PollerStats() = %+v, want %+v", stats, want)
+	}
+	if client.FlagConfigVersion() != version {
+		t.Errorf("version changed from %d to %d on not modified", version, client.FlagConfigVersion())
+	}
+}
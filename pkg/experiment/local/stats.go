@@ -0,0 +1,46 @@
+package local
+
+import "sync/atomic"
+
+// PollerStats counts the flag config fetches made by a client.
+type PollerStats struct {
+	// Polls is the number of fetches attempted, including the first one.
+	Polls uint64
+	// NotModified is the number of polls answered with 304 Not Modified,
+	// which left the flags unchanged without downloading them.
+	NotModified uint64
+	// Successes and Failures count the polls that did and did not leave the
+	// client with the current flag config. A rejected config is a failure.
+	Successes uint64
+	Failures  uint64
+	// ConsecutiveFailures is the number of failures since the last success.
+	ConsecutiveFailures uint64
+}
+
+type pollerStats struct {
+	polls               atomic.Uint64
+	notModified         atomic.Uint64
+	successes           atomic.Uint64
+	failures            atomic.Uint64
+	consecutiveFailures atomic.Uint64
+}
+
+func (s *pollerStats) record(err error) {
+	if err != nil {
+		s.failures.Add(1)
+		s.consecutiveFailures.Add(1)
+		return
+	}
+	s.successes.Add(1)
+	s.consecutiveFailures.Store(0)
+}
+
+func (c *Client) PollerStats() PollerStats {
+	return PollerStats{
+		Polls:               c.stats.polls.Load(),
+		NotModified:         c.stats.notModified.Load(),
+		Successes:           c.stats.successes.Load(),
+		Failures:            c.stats.failures.Load(),
+		ConsecutiveFailures: c.stats.consecutiveFailures.Load(),
+	}
+}
@@ -0,0 +1,52 @@
+package local
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// stream keeps a subscription to the flag config source open until ctx is
+// done, resubscribing with exponential backoff.
+func (c *Client) stream(ctx context.Context, subscriber FlagConfigSubscriber) {
+	backoff := c.config.StreamReconnectBackoffMin
+	for {
+		connected := false
+		err := subscriber.Subscribe(ctx, func() {
+			connected = true
+			c.streaming.Store(true)
+			c.log.Debug("flag stream connected")
+		}, func(config *FlagConfig) {
+			c.log.Debug("flag stream update")
+			_ = c.applyStreamedFlags(config)
+		})
+		c.streaming.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			backoff = c.config.StreamReconnectBackoffMin
+		}
+		c.log.Error("flag stream disconnected, polling until reconnected: %v", err)
+		// Jitter spreads reconnects from many clients after a server restart.
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		backoff *= 2
+		if backoff > c.config.StreamReconnectBackoffMax {
+			backoff = c.config.StreamReconnectBackoffMax
+		}
+	}
+}
+
+func (c *Client) applyStreamedFlags(config *FlagConfig) error {
+	err := c.applyFlags(config)
+	c.status.record(err)
+	if err != nil {
+		c.rejectFlags(err)
+	}
+	return err
+}
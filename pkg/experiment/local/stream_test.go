@@ -0,0 +1,226 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
+
+// streamEvents writes each string received from events to the stream and
+// returns when events is closed or the request is cancelled.
+func streamEvents(events <-chan string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				fmt.Fprint(w, event)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}
+}
+
+// sseEvent frames body as a server-sent event with one data line per line.
+func sseEvent(body string) string {
+	return "data: " + strings.ReplaceAll(body, "\n", "\ndata: ") + "\n\n"
+}
+
+func TestSubscribeFraming(t *testing.T) {
+	events := make(chan string, 1)
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdk/stream/v1/flags" || r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("unexpected stream request %s with Accept %q", r.URL.Path, r.Header.Get("Accept"))
+		}
+		streamEvents(events)(w, r)
+	})
+	events <- ": keep-alive\n\n" +
+		"data: [\n" +
+		"data:1]\n\n" +
+		"event: flags\r\ndata: x\r\n\r\n" +
+		"\n" +
+		"data: unterminated\n"
+	close(events)
+	source := &HTTPFlagConfigSource{ServerUrl: server.URL, ApiKey: "key"}
+	connected := false
+	var updates []string
+	err := source.Subscribe(context.Background(), func() {
+		connected = true
+	}, func(config *FlagConfig) {
+		updates = append(updates, string(config.Flags))
+	})
+	if err == nil || !connected {
+		t.Errorf("Subscribe() = %v, connected %v, want an error after connecting", err, connected)
+	}
+	want := []string{"[\n1]", "x"}
+	if fmt.Sprint(updates) != fmt.Sprint(want) {
+		t.Errorf("updates = %q, want %q", updates, want)
+	}
+}
+
+func TestSubscribeKeepAliveTimeout(t *testing.T) {
+	server := newTestServer(t, streamEvents(nil))
+	source := &HTTPFlagConfigSource{ServerUrl: server.URL, ApiKey: "key", StreamKeepAliveTimeout: 50 * time.Millisecond}
+	start := time.Now()
+	err := source.Subscribe(context.Background(), func() {}, func(*FlagConfig) {
+		t.Error("unexpected update")
+	})
+	if err == nil || !strings.Contains(err.Error(), "no data received") {
+		t.Errorf("Subscribe() error = %v, want a keep-alive timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Subscribe() returned after %v, before the keep-alive timeout", elapsed)
+	}
+}
+
+// failingSubscriber records when it is subscribed to and fails every
+// subscription, connecting first on the attempt numbered connectOn.
+type failingSubscriber struct {
+	*MemoryFlagConfigSource
+	connectOn int
+	mutex     sync.Mutex
+	attempts  []time.Time
+}
+
+func (s *failingSubscriber) Subscribe(ctx context.Context, connected func(), update func(*FlagConfig)) error {
+	s.mutex.Lock()
+	s.attempts = append(s.attempts, time.Now())
+	attempt := len(s.attempts)
+	s.mutex.Unlock()
+	if attempt == s.connectOn {
+		connected()
+	}
+	return fmt.Errorf("attempt %d failed", attempt)
+}
+
+func (s *failingSubscriber) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.attempts)
+}
+
+func TestStreamReconnectBackoff(t *testing.T) {
+	source := &failingSubscriber{MemoryFlagConfigSource: NewMemoryFlagConfigSource([]byte(testFlags)), connectOn: 5}
+	client := newTestClient(t, &Config{
+		FlagConfigSource:          source,
+		FlagConfigPollerInterval:  time.Hour,
+		StreamUpdates:             true,
+		StreamReconnectBackoffMin: 20 * time.Millisecond,
+		StreamReconnectBackoffMax: 160 * time.Millisecond,
+	})
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "six subscriptions", func() bool {
+		return source.count() >= 6
+	})
+	source.mutex.Lock()
+	attempts := source.attempts
+	source.mutex.Unlock()
+	// Each delay is between half and all of the backoff, which doubles up to
+	// the maximum and is reset by a connected attempt.
+	for i, backoff := range []time.Duration{20, 40, 80, 160} {
+		backoff *= time.Millisecond
+		if gap := attempts[i+1].Sub(attempts[i]); gap < backoff/2 {
+			t.Errorf("reconnect %d after %v, want at least %v", i+1, gap, backoff/2)
+		}
+	}
+	if gap := attempts[5].Sub(attempts[4]); gap >= 80*time.Millisecond {
+		t.Errorf("reconnect after connecting took %v, want the backoff reset to 20ms", gap)
+	}
+}
+
+func TestStreamPausesPolling(t *testing.T) {
+	var polls atomic.Int32
+	events := make(chan string)
+	var stream atomic.Bool
+	stream.Store(true)
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sdk/stream/v1/flags" {
+			if !stream.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			streamEvents(events)(w, r)
+			return
+		}
+		polls.Add(1)
+		serveFlags(testFlags)(w, r)
+	})
+	client := newTestClient(t, &Config{
+		ServerUrl:                 server.URL,
+		FlagConfigPollerInterval:  10 * time.Millisecond,
+		StreamUpdates:             true,
+		StreamReconnectBackoffMin: time.Hour,
+		StreamReconnectBackoffMax: time.Hour,
+	})
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the stream to connect", client.streaming.Load)
+	// Let a poll that started before the stream connected finish.
+	time.Sleep(20 * time.Millisecond)
+	paused := polls.Load()
+	time.Sleep(50 * time.Millisecond)
+	if polls.Load() != paused {
+		t.Errorf("%d polls while the stream was connected", polls.Load()-paused)
+	}
+	stream.Store(false)
+	close(events)
+	waitFor(t, "polling to resume", func() bool {
+		return polls.Load() >= paused+3
+	})
+}
+
+func TestStreamStaleAfterRejectedUpdate(t *testing.T) {
+	events := make(chan string)
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sdk/stream/v1/flags" {
+			streamEvents(events)(w, r)
+			return
+		}
+		serveFlags(testFlags)(w, r)
+	})
+	client := newTestClient(t, &Config{
+		ServerUrl:                 server.URL,
+		FlagConfigPollerInterval:  time.Hour,
+		MaxFlagConfigAge:          50 * time.Millisecond,
+		StreamUpdates:             true,
+		StreamReconnectBackoffMin: time.Hour,
+		StreamReconnectBackoffMax: time.Hour,
+	})
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the stream to connect", client.streaming.Load)
+	events <- sseEvent(testFlags)
+	time.Sleep(80 * time.Millisecond)
+	if client.Stale() {
+		t.Error("Stale() = true while the stream is connected and its update was accepted")
+	}
+	events <- sseEvent("[{]")
+	waitFor(t, "the streamed config to be rejected", func() bool {
+		return client.LastError() != nil
+	})
+	if !client.Stale() {
+		t.Error("Stale() = false after the streamed config was rejected")
+	}
+	variants, err := client.Evaluate(&experiment.User{UserId: "u"}, []string{"f"})
+	if err != nil || variants["f"].Value != "on" {
+		t.Errorf("Evaluate() = %v, %v, want the accepted flags", variants, err)
+	}
+}
@@ -1,15 +1,10 @@
 package local
 
-type evaluationVariant struct {
-	Key     string      `json:"key,omitempty"`
-	Payload interface{} `json:"payload,omitempty"`
-}
+import "github.com/LambdaTest/lambda-featureflag-go-sdk/internal/evaluation"
 
-type flagResult struct {
-	Variant          evaluationVariant `json:"variant,omitempty"`
-	Description      string            `json:"description,omitempty"`
-	IsDefaultVariant bool              `json:"isDefaultVariant,omitempty"`
-}
+type evaluationVariant = evaluation.Variant
+
+type flagResult = evaluation.Result
 
 type evaluationResult = map[string]flagResult
 
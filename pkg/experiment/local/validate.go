@@ -0,0 +1,89 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrFlagConfigRejected matches errors for fetched flag configs that failed
+// validation and did not replace the current flags.
+var ErrFlagConfigRejected = errors.New("flag config rejected")
+
+func rejectedf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrFlagConfigRejected, fmt.Sprintf(format, args...))
+}
+
+func validateFlagsResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return rejectedf("flags request resulted in error response %v", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return rejectedf("invalid content type %q", contentType)
+	}
+	// Error pages from proxies are typically HTML; servers that do not label
+	// the body as JSON still pass and are checked when it is parsed.
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"),
+		mediaType == "text/plain", mediaType == "application/octet-stream":
+		return nil
+	}
+	return rejectedf("unexpected content type %q", contentType)
+}
+
+// validateFlagSet checks next before it replaces current, which is nil
+// before the first flags are stored.
+func (c *Client) validateFlagSet(next, current *flagSet) error {
+	if current.empty() {
+		if next.empty() && c.config.RejectEmptyInitialFlagConfig {
+			return rejectedf("empty flag config")
+		}
+		return nil
+	}
+	if next.empty() && !c.config.AllowEmptyFlagConfig {
+		return rejectedf("empty flag config would replace %v flags", len(current.flags))
+	}
+	if c.config.FlagConfigMaxShrink > 0 {
+		removed := 0
+		for key := range current.index {
+			if next.index[key] == nil {
+				removed++
+			}
+		}
+		shrink := float64(removed) / float64(len(current.flags))
+		if shrink > c.config.FlagConfigMaxShrink {
+			return rejectedf("%v of %v flags removed, more than the allowed %v", removed, len(current.flags), c.config.FlagConfigMaxShrink)
+		}
+	}
+	return nil
+}
+
+// unchangedRejectionError is returned in place of a rejection for a flag
+// config that is unchanged since it was rejected. It fails the update like
+// the original rejection but is not reported again.
+type unchangedRejectionError struct {
+	err error
+}
+
+func (e *unchangedRejectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unchangedRejectionError) Unwrap() error {
+	return e.err
+}
+
+// rejectFlags reports a flag config that failed validation.
+func (c *Client) rejectFlags(err error) {
+	c.log.Error("%v", err)
+	if c.config.OnFlagConfigRejected != nil {
+		c.config.OnFlagConfigRejected(err)
+	}
+}
@@ -0,0 +1,135 @@
+package local
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInitialEmptyFlagConfig(t *testing.T) {
+	tests := []struct {
+		reject bool
+		want   error
+	}{
+		{false, nil},
+		{true, ErrFlagConfigRejected},
+	}
+	for _, tt := range tests {
+		var requests atomic.Int32
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Write([]byte("[]"))
+		})
+		client := newTestClient(t, &Config{
+			ServerUrl:                    server.URL,
+			RejectEmptyInitialFlagConfig: tt.reject,
+			InitialFetchRetries:          2,
+			InitialFetchRetryBackoff:     time.Second,
+			FlagConfigPollerInterval:     time.Hour,
+		})
+		start := time.Now()
+		err := client.Start()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("reject %v: Start() = %v, want %v", tt.reject, err, tt.want)
+		}
+		if n := requests.Load(); n != 1 || time.Since(start) > 500*time.Millisecond {
+			t.Errorf("reject %v: %d requests in %v, want 1 without retries", tt.reject, n, time.Since(start))
+		}
+		if ready := client.Ready(); ready != (tt.want == nil) {
+			t.Errorf("reject %v: Ready() = %v", tt.reject, ready)
+		}
+	}
+}
+
+func TestValidateFlagsResponse(t *testing.T) {
+	tests := []struct {
+		status      int
+		contentType string
+		rejected    bool
+	}{
+		{http.StatusOK, "", false},
+		{http.StatusOK, "application/json", false},
+		{http.StatusOK, "application/json; charset=utf-8", false},
+		{http.StatusOK, "application/vnd.flags+json", false},
+		{http.StatusOK, "text/plain", false},
+		{http.StatusOK, "application/octet-stream", false},
+		{http.StatusOK, "text/html; charset=utf-8", true},
+		{http.StatusOK, "not a/media type;", true},
+		{http.StatusNoContent, "application/json", true},
+		{http.StatusInternalServerError, "application/json", true},
+		{http.StatusBadGateway, "text/html", true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.contentType != "" {
+			resp.Header.Set("Content-Type", tt.contentType)
+		}
+		err := validateFlagsResponse(resp)
+		if rejected := errors.Is(err, ErrFlagConfigRejected); rejected != tt.rejected {
+			t.Errorf("%d %q: error %v, want rejected %v", tt.status, tt.contentType, err, tt.rejected)
+		}
+	}
+}
+
+func TestFlagConfigRejected(t *testing.T) {
+	oneFlag := `[{"flagKey":"f","enabled":true,"variants":[{"key":"on"}]}]`
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		config    Config
+		rejected  bool
+		wantFlags int
+	}{
+		{name: "error status", handler: func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "[]", http.StatusInternalServerError)
+		}, rejected: true, wantFlags: 2},
+		{name: "html body", handler: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		}, rejected: true, wantFlags: 2},
+		{name: "empty body", handler: serveFlags(""), rejected: true, wantFlags: 2},
+		{name: "null body", handler: serveFlags("null"), rejected: true, wantFlags: 2},
+		{name: "object body", handler: serveFlags(`{"flags":[]}`), rejected: true, wantFlags: 2},
+		{name: "malformed flag", handler: serveFlags(`[{"flagKey":"f","enabled":"yes"}]`), rejected: true, wantFlags: 2},
+		{name: "duplicate flag", handler: serveFlags(`[{"flagKey":"f"},{"flagKey":"f"}]`), rejected: true, wantFlags: 2},
+		{name: "empty config", handler: serveFlags("[]"), rejected: true, wantFlags: 2},
+		{name: "empty config allowed", handler: serveFlags("[]"),
+			config: Config{AllowEmptyFlagConfig: true}, wantFlags: 0},
+		{name: "shrink over limit", handler: serveFlags(oneFlag),
+			config: Config{FlagConfigMaxShrink: 0.4}, rejected: true, wantFlags: 2},
+		{name: "shrink at limit", handler: serveFlags(oneFlag),
+			config: Config{FlagConfigMaxShrink: 0.5}, wantFlags: 1},
+		{name: "shrink unchecked", handler: serveFlags(oneFlag), wantFlags: 1},
+	}
+	for _, tt := range tests {
+		server := newTestServer(t, serveFlags(testFlags))
+		var reported []error
+		config := tt.config
+		config.ServerUrl = server.URL
+		config.FlagConfigPollerInterval = time.Hour
+		config.OnFlagConfigRejected = func(err error) {
+			reported = append(reported, err)
+		}
+		client := newTestClient(t, &config)
+		err := client.Start()
+		if err != nil {
+			t.Fatalf("%s: Start() = %v", tt.name, err)
+		}
+		server.setHandler(tt.handler)
+		err = client.updateFlags(client.ctx)
+		if rejected := errors.Is(err, ErrFlagConfigRejected); rejected != tt.rejected {
+			t.Errorf("%s: updateFlags() = %v, want rejected %v", tt.name, err, tt.rejected)
+		}
+		if tt.rejected && (len(reported) != 1 || reported[0] != err) {
+			t.Errorf("%s: OnFlagConfigRejected called with %v, want %v", tt.name, reported, err)
+		}
+		if !tt.rejected && len(reported) != 0 {
+			t.Errorf("%s: OnFlagConfigRejected called with %v", tt.name, reported)
+		}
+		if n := len(client.FlagKeys()); n != tt.wantFlags {
+			t.Errorf("%s: %d flags loaded, want %d", tt.name, n, tt.wantFlags)
+		}
+	}
+}
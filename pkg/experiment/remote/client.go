@@ -29,30 +29,44 @@ type Client struct {
 
 func Initialize(apiKey string, config *Config) *Client {
 	initMutex.Lock()
+	defer initMutex.Unlock()
 	client := clients[apiKey]
 	if client == nil {
 		if apiKey == "" {
 			panic("api key must be set")
 		}
 		config = fillConfigDefaults(config)
+		httpClient := config.HTTPClient
+		if httpClient == nil {
+			var err error
+			httpClient, err = experiment.NewHTTPClient(config.HTTP)
+			if err != nil {
+				panic(err)
+			}
+		}
 		client = &Client{
 			log:    logger.New(config.Debug),
 			apiKey: apiKey,
 			config: config,
-			client: &http.Client{},
+			client: httpClient,
 		}
 		client.log.Debug("config: %v", *config)
 	}
-	initMutex.Unlock()
 	return client
 }
 
 func (c *Client) Fetch(user *experiment.User) (map[string]experiment.Variant, error) {
-	variants, err := c.doFetch(user, c.config.FetchTimeout)
+	return c.FetchContext(context.Background(), user)
+}
+
+// FetchContext is Fetch bounded by ctx as well as the configured timeouts.
+// Retries stop once ctx is done, and ctx is passed on with the requests.
+func (c *Client) FetchContext(ctx context.Context, user *experiment.User) (map[string]experiment.Variant, error) {
+	variants, err := c.doFetch(ctx, user, c.config.FetchTimeout)
 	if err != nil {
 		c.log.Error("fetch error: %v", err)
-		if c.config.RetryBackoff.FetchRetries > 0 {
-			return c.retryFetch(user)
+		if c.config.RetryBackoff.FetchRetries > 0 && ctx.Err() == nil {
+			return c.retryFetch(ctx, user)
 		} else {
 			return nil, err
 		}
@@ -60,7 +74,7 @@ func (c *Client) Fetch(user *experiment.User) (map[string]experiment.Variant, er
 	return variants, err
 }
 
-func (c *Client) doFetch(user *experiment.User, timeout time.Duration) (map[string]experiment.Variant, error) {
+func (c *Client) doFetch(ctx context.Context, user *experiment.User, timeout time.Duration) (map[string]experiment.Variant, error) {
 	addLibraryContext(user)
 	endpoint, err := url.Parse(c.config.ServerUrl)
 	if err != nil {
@@ -75,7 +89,7 @@ func (c *Client) doFetch(user *experiment.User, timeout time.Duration) (map[stri
 		return nil, err
 	}
 	c.log.Debug("fetch variants for user %s", string(jsonBytes))
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	req, err := http.NewRequest("POST", endpoint.String(), bytes.NewBuffer(jsonBytes))
 	if err != nil {
@@ -97,7 +111,7 @@ func (c *Client) doFetch(user *experiment.User, timeout time.Duration) (map[stri
 	return c.parseResponse(resp)
 }
 
-func (c *Client) retryFetch(user *experiment.User) (map[string]experiment.Variant, error) {
+func (c *Client) retryFetch(ctx context.Context, user *experiment.User) (map[string]experiment.Variant, error) {
 	var err error
 	var variants map[string]experiment.Variant
 	var timer *time.Timer
@@ -105,8 +119,13 @@ func (c *Client) retryFetch(user *experiment.User) (map[string]experiment.Varian
 	for i := 0; i < c.config.RetryBackoff.FetchRetries; i++ {
 		c.log.Debug("retry attempt %v", i)
 		timer = time.NewTimer(delay)
-		<-timer.C
-		variants, err = c.doFetch(user, c.config.RetryBackoff.FetchRetryTimeout)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		variants, err = c.doFetch(ctx, user, c.config.RetryBackoff.FetchRetryTimeout)
 		if err == nil && variants != nil {
 			c.log.Debug("retry attempt %v success", i)
 			return variants, nil
@@ -1,12 +1,21 @@
 package remote
 
-import "time"
+import (
+	"net/http"
+	"time"
+
+	"github.com/LambdaTest/lambda-featureflag-go-sdk/pkg/experiment"
+)
 
 type Config struct {
 	Debug        bool
 	ServerUrl    string
 	FetchTimeout time.Duration
 	RetryBackoff *RetryBackoff
+	// HTTPClient sends the client's requests. When it is nil a client is
+	// built from HTTP.
+	HTTPClient *http.Client
+	HTTP       *experiment.HTTPConfig
 }
 
 var DefaultConfig = &Config{
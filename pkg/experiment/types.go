@@ -24,4 +24,7 @@ type User struct {
 type Variant struct {
 	Value   string      `json:"value,omitempty"`
 	Payload interface{} `json:"payload,omitempty"`
+	// IsDefaultVariant is set by local evaluation when the flag served its
+	// default variant and default variants were requested.
+	IsDefaultVariant bool `json:"is_default_variant,omitempty"`
 }